@@ -0,0 +1,28 @@
+package main
+
+import (
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/app"
+	"go-war-ticket-service/internal/platform/logger"
+	"log"
+)
+
+func main() {
+	// Load Configurations
+	cfg, err := configs.LoadConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	// Setup Logger
+	isDevelopment := cfg.ServerMode == "development"
+	appLogger := logger.New(isDevelopment)
+	defer appLogger.Sync() // Flush logs
+
+	// Start Worker
+	worker := app.NewWorkerServer(cfg, appLogger)
+
+	if err := worker.Start(); err != nil {
+		appLogger.Fatal("worker failed to start: ", err)
+	}
+}
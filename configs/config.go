@@ -1,6 +1,10 @@
 package configs
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
 	// Server configurations
@@ -40,6 +44,69 @@ type Config struct {
 
 	// Message Broker configurations
 	RabbitMQURL string `mapstructure:"RABBITMQ_URL"`
+
+	// Queue consumer configurations
+	QueueMaxAttempts   int           `mapstructure:"QUEUE_MAX_ATTEMPTS"`    // failed deliveries before a message is dead-lettered
+	QueueRetryDelay    time.Duration `mapstructure:"QUEUE_RETRY_DELAY"`     // wait before the first retry, doubled each time
+	QueueRetryMaxDelay time.Duration `mapstructure:"QUEUE_RETRY_MAX_DELAY"` // upper bound for the retry wait
+
+	// Worker configurations
+	DisableEmbeddedWorker         bool          `mapstructure:"DISABLE_EMBEDDED_WORKER"`         // leave the queues to cmd/worker instead of the API process
+	TicketWorkerConcurrency       int           `mapstructure:"TICKET_WORKER_CONCURRENCY"`       // orders whose tickets are generated at once
+	TicketWorkerPrefetch          int           `mapstructure:"TICKET_WORKER_PREFETCH"`          // unacked messages buffered, defaults to twice the concurrency
+	NotificationWorkerConcurrency int           `mapstructure:"NOTIFICATION_WORKER_CONCURRENCY"` // emails sent at once
+	NotificationWorkerPrefetch    int           `mapstructure:"NOTIFICATION_WORKER_PREFETCH"`
+	ShutdownTimeout               time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"` // how long in-flight requests and jobs get to finish
+
+	// Outbox configurations
+	OutboxRelayInterval time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"` // how often pending messages are published
+	OutboxBatchSize     int           `mapstructure:"OUTBOX_BATCH_SIZE"`     // messages published per transaction
+	OutboxRetention     time.Duration `mapstructure:"OUTBOX_RETENTION"`      // how long sent messages are kept
+
+	// Order configurations
+	OrderReservationTTL      time.Duration `mapstructure:"ORDER_RESERVATION_TTL"`       // how long a PENDING order holds its seats
+	OrderExpirySweepInterval time.Duration `mapstructure:"ORDER_EXPIRY_SWEEP_INTERVAL"` // how often stale orders are released
+	OrderFeePerTicket        float64       `mapstructure:"ORDER_FEE_PER_TICKET"`        // service fee added for every ticket
+	OrderCurrency            string        `mapstructure:"ORDER_CURRENCY"`              // ISO 4217 code stored on each order
+	IdempotencyKeyTTL        time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`         // how long order responses are kept for retries
+	OrderMaxTicketsPerUser   int           `mapstructure:"ORDER_MAX_TICKETS_PER_USER"`  // default per event limit, 0 means no limit
+
+	// Stock reconciliation configurations
+	StockReconcileInterval time.Duration `mapstructure:"STOCK_RECONCILE_INTERVAL"` // how often Redis and Postgres stock are compared
+	StockReconcileRepair   bool          `mapstructure:"STOCK_RECONCILE_REPAIR"`   // rewrite drifted stock from the orders
+
+	// Waiting room configurations
+	WaitingRoomEnabled       bool          `mapstructure:"WAITING_ROOM_ENABLED"`        // require an admission token to create orders
+	WaitingRoomAdmitRate     int           `mapstructure:"WAITING_ROOM_ADMIT_RATE"`     // users let in per event every interval
+	WaitingRoomAdmitInterval time.Duration `mapstructure:"WAITING_ROOM_ADMIT_INTERVAL"` // how often the queue is drained
+	WaitingRoomAdmissionTTL  time.Duration `mapstructure:"WAITING_ROOM_ADMISSION_TTL"`  // how long an admitted user may place orders
+	WaitingRoomQueueTTL      time.Duration `mapstructure:"WAITING_ROOM_QUEUE_TTL"`      // queued users who stop polling are dropped after this
+
+	// Payment configurations
+	PaymentProvider      string `mapstructure:"PAYMENT_PROVIDER"`       // midtrans or fake
+	PaymentWebhookSecret string `mapstructure:"PAYMENT_WEBHOOK_SECRET"` // HMAC secret for the fake provider
+	MidtransServerKey    string `mapstructure:"MIDTRANS_SERVER_KEY"`
+	MidtransIsProduction bool   `mapstructure:"MIDTRANS_IS_PRODUCTION"`
+
+	// Mail configurations
+	MailDriver   string `mapstructure:"MAIL_DRIVER"` // smtp, file or memory
+	MailFrom     string `mapstructure:"MAIL_FROM"`
+	MailFileDir  string `mapstructure:"MAIL_FILE_DIR"` // where the file driver writes .eml files
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     int    `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+
+	// Notification configurations
+	NotificationAttachMaxSize int64 `mapstructure:"NOTIFICATION_ATTACH_MAX_SIZE"` // bytes of PDFs to attach before sending links instead
+
+	// Account configurations
+	AppBaseURL                string        `mapstructure:"APP_BASE_URL"` // frontend URL used in email links
+	EmailVerificationTokenTTL time.Duration `mapstructure:"EMAIL_VERIFICATION_TOKEN_TTL"`
+	PasswordResetTokenTTL     time.Duration `mapstructure:"PASSWORD_RESET_TOKEN_TTL"`
+
+	// Ticket configurations
+	TicketQRPrivateKey string `mapstructure:"TICKET_QR_PRIVATE_KEY"` // base64 encoded 32-byte Ed25519 seed
 }
 
 func LoadConfig() (cfg Config, err error) {
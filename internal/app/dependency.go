@@ -1,19 +1,27 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/features/auth"
+	"go-war-ticket-service/internal/features/deadletter"
 	"go-war-ticket-service/internal/features/event"
 	"go-war-ticket-service/internal/features/order"
 	"go-war-ticket-service/internal/features/ticket"
 	"go-war-ticket-service/internal/features/user"
+	"go-war-ticket-service/internal/features/waitingroom"
 	"go-war-ticket-service/internal/platform/hash"
+	"go-war-ticket-service/internal/platform/idgen"
 	"go-war-ticket-service/internal/platform/jwt"
+	"go-war-ticket-service/internal/platform/mailer"
 	rabbitmq "go-war-ticket-service/internal/platform/message_broker/rabbit_mq"
 	"go-war-ticket-service/internal/platform/middleware"
-	"go-war-ticket-service/internal/platform/pdf"
+	"go-war-ticket-service/internal/platform/outbox"
+	"go-war-ticket-service/internal/platform/payment"
 	"go-war-ticket-service/internal/platform/validator"
 	"go-war-ticket-service/internal/utils"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/minio/minio-go/v7"
@@ -22,70 +30,241 @@ import (
 	"gorm.io/gorm"
 )
 
+const defaultMailFileDir = "./tmp/mail"
+
 // Dependencies holds all the dependencies for the application
 type Dependencies struct {
-	AuthHandler    auth.Handler
-	UserHandler    user.Handler
-	AuthMiddleware fiber.Handler
-	EventHandler   event.Handler
-	OrderHandler   order.Handler
+	AuthHandler        auth.Handler
+	UserHandler        user.Handler
+	AuthMiddleware     fiber.Handler
+	EventOwner         fiber.Handler
+	Idempotency        fiber.Handler
+	Admission          fiber.Handler
+	EventHandler       event.Handler
+	OrderHandler       order.Handler
+	TicketHandler      ticket.Handler
+	WaitingRoomHandler waitingroom.Handler
+	DeadLetterHandler  deadletter.Handler
+
+	publisher rabbitmq.Publisher
+	workers   *Workers
 }
 
-// Initialize and set up all dependencies
+// Shutdown waits for the embedded queue workers to finish the jobs they
+// hold, up to timeout, then closes the broker connection
+func (d *Dependencies) Shutdown(timeout time.Duration) error {
+	var err error
+	if d.workers != nil {
+		err = d.workers.Wait(timeout)
+	}
+
+	d.publisher.Close()
+	return err
+}
+
+// Initialize and set up all dependencies. A configuration mistake is
+// returned as an error rather than leaving the server half wired.
 func SetupDependencies(
+	ctx context.Context,
 	cfg configs.Config,
 	log *zap.SugaredLogger,
 	db *gorm.DB,
 	rdb *redis.Client,
 	s3 *minio.Client,
-) *Dependencies {
+) (*Dependencies, error) {
 	// Platform
 	hasher := hash.NewBcryptHasher()
-	jwtGen := jwt.NewJWTGenerator(cfg.JWTAccessSecret)
+	accessTTL, err := utils.ParseDuration(cfg.JWTAccessTokenExpire)
+	if err != nil {
+		log.Warnf("Invalid JWT_ACCESS_TOKEN_EXPIRE %q, using the default", cfg.JWTAccessTokenExpire)
+	}
+	jwtGen := jwt.NewJWTGenerator(cfg.JWTAccessSecret, accessTTL)
 	val := validator.New()
-	authMiddleware := middleware.AuthRequired(cfg.JWTAccessSecret, log)
+	ids := idgen.NewCrockford()
+	authMiddleware := middleware.AuthRequired(cfg.JWTAccessSecret, rdb, log)
 	mqPublisher, err := rabbitmq.NewRabbitMQPublisher(cfg.RabbitMQURL)
 	if err != nil {
-		log.Error("Failed to create RabbitMQ publisher", zap.Error(err))
-		return nil
+		return nil, fmt.Errorf("failed to create rabbitmq publisher: %w", err)
+	}
+	// An ephemeral key only works while the same process signs and verifies
+	qrSigner, err := newQRSigner(cfg, !cfg.DisableEmbeddedWorker, log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ticket QR private key: %w", err)
+	}
+	paymentProvider, err := newPaymentProvider(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payment provider: %w", err)
+	}
+	mail, err := newMailer(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create mailer: %w", err)
 	}
-	pdfGenerator := pdf.NewMarotoGenerator()
 
 	// Create new queue
-	mqPublisher.CreateQueue(utils.QueueTicketGeneration)
+	createQueues(mqPublisher)
 
 	// User Features
 	userRepo := user.NewRepository(db)
 	userUsecase := user.NewUsecase(userRepo, log, s3, cfg)
-	userHandler := user.NewHandler(userUsecase)
+	userHandler := user.NewHandler(userUsecase, val)
 
 	// Auth Features
 	authRepo := auth.NewRepository(db)
-	authUsecase := auth.NewUsecase(authRepo, userRepo, hasher, jwtGen, log, s3, cfg)
+	authUsecase := auth.NewUsecase(authRepo, userRepo, hasher, jwtGen, log, s3, cfg, rdb, mail)
 	authHandler := auth.NewHandler(authUsecase, val)
 
 	// Event Features
 	eventRepo := event.NewRepository(db)
-	eventUsecase := event.NewUsecase(eventRepo, log, s3, cfg)
+	eventUsecase := event.NewUsecase(eventRepo, log, s3, cfg, rdb)
 	eventHandler := event.NewHandler(eventUsecase, val)
 
 	// Order Features
 	orderRepo := order.NewRepository(db)
-	orderUsecase := order.NewUsecase(orderRepo, log, s3, cfg, rdb)
-	orderService := order.NewService(orderRepo, log, mqPublisher)
+	orderUsecase := order.NewUsecase(orderRepo, log, s3, cfg, rdb, paymentProvider, ids)
+	orderService := order.NewService(orderRepo, log, paymentProvider, rdb, s3, cfg)
 	orderHandler := order.NewHandler(orderUsecase, orderService, val)
+	orderSweeper := order.NewExpirySweeper(orderRepo, orderService, rdb, cfg, log)
+	stockReconciler := order.NewStockReconciler(orderRepo, orderService, cfg, log)
 
-	// Worker
+	// Waiting Room Features
+	waitingRoomRepo := waitingroom.NewRepository(db)
+	waitingRoomUsecase := waitingroom.NewUsecase(waitingRoomRepo, rdb, cfg, log)
+	waitingRoomHandler := waitingroom.NewHandler(waitingRoomUsecase)
+	admission := func(c *fiber.Ctx) error { return c.Next() }
+	if cfg.WaitingRoomEnabled {
+		admission = middleware.RequireAdmission(waitingRoomUsecase.CheckAdmission, log)
+		go waitingroom.NewAdmitter(rdb, cfg, log).Start(ctx)
+	}
+
+	// Ticket Features
 	ticketRepo := ticket.NewRepository(db)
-	ticketWorker := ticket.NewTicketWorker(mqPublisher.GetConnection(), ticketRepo, orderRepo, s3, cfg, pdfGenerator, log)
+	ticketUsecase := ticket.NewUsecase(ticketRepo, qrSigner.PublicKey(), ids, log)
+	ticketHandler := ticket.NewHandler(ticketUsecase, val)
+
+	// Dead Letter Features
+	deadLetterUsecase := deadletter.NewUsecase(mqPublisher, log)
+	deadLetterHandler := deadletter.NewHandler(deadLetterUsecase, val)
+
+	// Outbox
+	outboxRelay := outbox.NewRelay(db, mqPublisher, outbox.Config{
+		Interval:  cfg.OutboxRelayInterval,
+		BatchSize: cfg.OutboxBatchSize,
+		Retention: cfg.OutboxRetention,
+	}, log)
 
-	go ticketWorker.Start()
+	// Queue workers, unless they run on their own through cmd/worker
+	var workers *Workers
+	if cfg.DisableEmbeddedWorker {
+		log.Info("Embedded worker disabled, run cmd/worker to process queued jobs")
+	} else {
+		workers = StartWorkers(ctx, cfg, log, db, s3, mqPublisher, qrSigner, mail)
+	}
+
+	// Background loops stop with ctx on shutdown
+	go outboxRelay.Start(ctx)
+	go orderSweeper.Start(ctx)
+	go stockReconciler.Start(ctx)
 
 	return &Dependencies{
-		AuthHandler:    *authHandler,
-		UserHandler:    *userHandler,
-		AuthMiddleware: authMiddleware,
-		EventHandler:   *eventHandler,
-		OrderHandler:   *orderHandler,
+		AuthHandler:        *authHandler,
+		UserHandler:        *userHandler,
+		AuthMiddleware:     authMiddleware,
+		EventOwner:         middleware.RequireEventOwner(eventRepo.GetEventOrganiserID, log),
+		Idempotency:        middleware.Idempotency(rdb, cfg.IdempotencyKeyTTL, log),
+		Admission:          admission,
+		EventHandler:       *eventHandler,
+		OrderHandler:       *orderHandler,
+		TicketHandler:      *ticketHandler,
+		WaitingRoomHandler: *waitingRoomHandler,
+		DeadLetterHandler:  *deadLetterHandler,
+		publisher:          mqPublisher,
+		workers:            workers,
+	}, nil
+}
+
+// createQueues declares every queue the API and the workers exchange
+// messages through. Both sides call it, whichever starts first wins.
+func createQueues(mq rabbitmq.Publisher) {
+	mq.CreateQueue(utils.QueueTicketGeneration)
+	mq.CreateQueue(utils.QueueOrderRefunded)
+	mq.CreateQueue(utils.QueueTicketsReady)
+}
+
+// newQRSigner loads the ticket signing key. Development may fall back to an
+// ephemeral key, but only when allowEphemeral says the tickets it signs are
+// verified by the same process.
+func newQRSigner(cfg configs.Config, allowEphemeral bool, log *zap.SugaredLogger) (*ticket.QRSigner, error) {
+	key, err := ticket.ParseQRPrivateKey(cfg.TicketQRPrivateKey)
+	if err == nil {
+		return ticket.NewQRSigner(key), nil
+	}
+
+	if cfg.ServerMode != "development" || !allowEphemeral {
+		return nil, err
+	}
+
+	log.Warn("Ticket QR private key not configured, using an ephemeral key")
+	if key, err = ticket.GenerateQRPrivateKey(); err != nil {
+		return nil, err
+	}
+	return ticket.NewQRSigner(key), nil
+}
+
+// newPaymentProvider picks the payment gateway from config. The fake provider
+// is refused outside development so it can't end up taking real orders.
+func newPaymentProvider(cfg configs.Config) (order.PaymentProvider, error) {
+	switch cfg.PaymentProvider {
+	case "midtrans":
+		if cfg.MidtransServerKey == "" {
+			return nil, fmt.Errorf("MIDTRANS_SERVER_KEY is required for the midtrans provider")
+		}
+		return payment.NewMidtransProvider(cfg.MidtransServerKey, cfg.MidtransIsProduction), nil
+	case "fake":
+		if cfg.ServerMode != "development" {
+			return nil, fmt.Errorf("fake payment provider is only allowed in development")
+		}
+		if cfg.PaymentWebhookSecret == "" {
+			return nil, fmt.Errorf("PAYMENT_WEBHOOK_SECRET is required for the fake provider")
+		}
+		return payment.NewFakeProvider(cfg.PaymentWebhookSecret), nil
+	case "":
+		return nil, fmt.Errorf("PAYMENT_PROVIDER is not set, use midtrans or fake")
+	default:
+		return nil, fmt.Errorf("unknown payment provider %q", cfg.PaymentProvider)
+	}
+}
+
+// newMailer picks the email transport from config. The file and memory sinks
+// never deliver anything, so they are refused outside development. An unset
+// MAIL_DRIVER means file in development and smtp everywhere else.
+func newMailer(cfg configs.Config) (mailer.Mailer, error) {
+	driver := cfg.MailDriver
+	if driver == "" {
+		driver = "smtp"
+		if cfg.ServerMode == "development" {
+			driver = "file"
+		}
+	}
+
+	switch driver {
+	case "smtp":
+		if cfg.SMTPHost == "" || cfg.MailFrom == "" {
+			return nil, fmt.Errorf("SMTP_HOST and MAIL_FROM are required for the smtp mail driver")
+		}
+		return mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case "file", "memory":
+		if cfg.ServerMode != "development" {
+			return nil, fmt.Errorf("the %s mail driver is only allowed in development", driver)
+		}
+		if driver == "memory" {
+			return mailer.NewMemoryMailer(), nil
+		}
+		dir := cfg.MailFileDir
+		if dir == "" {
+			dir = defaultMailFileDir
+		}
+		return mailer.NewFileMailer(dir, cfg.MailFrom)
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q, use smtp, file or memory", driver)
 	}
 }
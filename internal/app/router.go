@@ -1,6 +1,9 @@
 package app
 
 import (
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/middleware"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 )
@@ -21,28 +24,61 @@ func SetupRoutes(app *fiber.App, deps *Dependencies) {
 	authGroup := v1.Group("/auth")
 	authGroup.Post("/register", deps.AuthHandler.Register)
 	authGroup.Post("/login", deps.AuthHandler.Login)
+	authGroup.Post("/refresh", deps.AuthHandler.Refresh)
+	authGroup.Post("/logout", deps.AuthMiddleware, deps.AuthHandler.Logout)
+	authGroup.Post("/verify-email", deps.AuthHandler.VerifyEmail)
+	authGroup.Post("/verify-email/resend", middleware.RateLimit(), deps.AuthMiddleware, deps.AuthHandler.ResendVerificationEmail)
+	authGroup.Post("/forgot-password", middleware.RateLimit(), deps.AuthHandler.ForgotPassword)
+	authGroup.Post("/reset-password", deps.AuthHandler.ResetPassword)
 
 	// User routes
 	userGroup := v1.Group("/user")
 	userGroup.Use(deps.AuthMiddleware)
 	userGroup.Get("/me", deps.UserHandler.GetMyProfile)
+	userGroup.Patch("/:user_id/role", middleware.RequireRoles(domain.RoleAdmin), deps.UserHandler.UpdateUserRole)
 
 	// Event routes
 	eventGroup := v1.Group("/event")
 	eventGroup.Use(deps.AuthMiddleware)
 	eventGroup.Get("/:event_id", deps.EventHandler.GetEventByID)
 	eventGroup.Get("/", deps.EventHandler.GetAllEvent)
-	eventGroup.Post("/", deps.EventHandler.CreateEvent)
-	eventGroup.Delete("/:event_id", deps.EventHandler.DeleteEvent)
+	eventGroup.Post("/", middleware.RequireRoles(domain.RoleOrganiser, domain.RoleAdmin), deps.EventHandler.CreateEvent)
+	eventGroup.Patch("/:event_id", deps.EventOwner, deps.EventHandler.UpdateEvent)
+	eventGroup.Delete("/:event_id", deps.EventOwner, deps.EventHandler.DeleteEvent)
+	eventGroup.Post("/:event_id/stock/reconcile", middleware.RequireRoles(domain.RoleAdmin), deps.OrderHandler.ReconcileStock)
+
+	// Waiting room routes
+	waitingRoomGroup := v1.Group("/waiting-room")
+	waitingRoomGroup.Use(deps.AuthMiddleware)
+	waitingRoomGroup.Post("/:event_id", deps.WaitingRoomHandler.Join)
+	waitingRoomGroup.Get("/:event_id", deps.WaitingRoomHandler.GetPlace)
+	waitingRoomGroup.Delete("/:event_id", deps.WaitingRoomHandler.Leave)
 
 	// Order routes
 	orderGroup := v1.Group("/order")
 	orderGroup.Use(deps.AuthMiddleware)
-	orderGroup.Post("/", deps.OrderHandler.CreateOrder)
+	orderGroup.Post("/", deps.Admission, deps.Idempotency, deps.OrderHandler.CreateOrder)
 	orderGroup.Get("/:booking_id", deps.OrderHandler.GetOrderByBookingID)
+	orderGroup.Get("/:booking_id/history", deps.OrderHandler.GetOrderStatusHistory)
+	orderGroup.Post("/:booking_id/cancel", deps.OrderHandler.CancelOrder)
+	orderGroup.Post("/:booking_id/refund", middleware.RequireRoles(domain.RoleAdmin), deps.OrderHandler.RefundOrder)
+	orderGroup.Post("/:booking_id/requeue", middleware.RequireRoles(domain.RoleAdmin), deps.OrderHandler.RequeueOrder)
 	orderGroup.Get("/", deps.OrderHandler.GetOrderList)
-	
-	// Order Webhook routes
-	orderWebhookGroup := v1.Group("/order/webhook")
-	orderWebhookGroup.Post("/payment", deps.OrderHandler.ProcessPaymentWebhook)
+
+	// Ticket routes
+	ticketGroup := v1.Group("/ticket")
+	ticketGroup.Use(deps.AuthMiddleware)
+	ticketGroup.Post("/check-in", middleware.RequireRoles(domain.RoleGateStaff, domain.RoleAdmin), deps.TicketHandler.CheckIn)
+	ticketGroup.Get("/qr-public-key", deps.TicketHandler.GetQRPublicKey)
+
+	// Admin routes
+	adminGroup := v1.Group("/admin")
+	adminGroup.Use(deps.AuthMiddleware, middleware.RequireRoles(domain.RoleAdmin))
+	adminGroup.Get("/dead-letters/:queue", deps.DeadLetterHandler.ListDeadLetters)
+	adminGroup.Post("/dead-letters/:queue/replay", deps.DeadLetterHandler.ReplayDeadLetters)
+
+	// Webhook routes. Providers authenticate with a signature, not a JWT, so
+	// these must stay outside every group that uses AuthMiddleware.
+	webhookGroup := v1.Group("/webhooks")
+	webhookGroup.Post("/payment", deps.OrderHandler.ProcessPaymentWebhook)
 }
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/features/order"
+	"go-war-ticket-service/internal/platform/payment"
+	"go-war-ticket-service/internal/platform/validator"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// webhookRepo holds a single PENDING order, every other Repository method
+// panics through the nil embedded interface
+type webhookRepo struct {
+	order.Repository
+	order  domain.Order
+	paidBy string
+}
+
+func (r *webhookRepo) GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error) {
+	if bookingID != r.order.BookingID {
+		return &domain.Order{}, nil
+	}
+	o := r.order
+	return &o, nil
+}
+
+func (r *webhookRepo) TransitionOrderStatus(ctx context.Context, bookingID string, to domain.OrderStatus, change order.StatusChange, messages ...domain.OutboxMessage) (*domain.Order, error) {
+	r.order.Status = to
+	r.paidBy = change.Actor
+	return &r.order, nil
+}
+
+func newWebhookApp(t *testing.T, provider *payment.FakeProvider, repo *webhookRepo) *fiber.App {
+	t.Helper()
+
+	log := zap.NewNop().Sugar()
+	svc := order.NewService(repo, log, provider, nil, nil, configs.Config{})
+
+	pass := func(c *fiber.Ctx) error { return c.Next() }
+	deps := &Dependencies{
+		// Any route behind authentication is rejected outright
+		AuthMiddleware: func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusUnauthorized) },
+		EventOwner:     pass,
+		Idempotency:    pass,
+		Admission:      pass,
+		OrderHandler:   *order.NewHandler(nil, svc, validator.New()),
+	}
+
+	app := fiber.New()
+	SetupRoutes(app, deps)
+	return app
+}
+
+func TestPaymentWebhookNeedsNoJWT(t *testing.T) {
+	provider := payment.NewFakeProvider("webhook-secret")
+	repo := &webhookRepo{order: domain.Order{
+		BaseModel:  domain.BaseModel{ID: uuid.New()},
+		BookingID:  "WT-TESTBOOKING",
+		Status:     domain.OrderStatusPending,
+		TotalPrice: 15000000,
+	}}
+	app := newWebhookApp(t, provider, repo)
+
+	body := []byte(`{"booking_id":"WT-TESTBOOKING","payment_status":"PAID","gross_amount":"150000.00"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/payment", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payment.FakeSignatureHeader, hex.EncodeToString(provider.Sign(body)))
+
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.StatusCode)
+	}
+	if repo.order.Status != domain.OrderStatusPaid || repo.paidBy != order.ActorPaymentWebhook {
+		t.Fatalf("expected the webhook to mark the order PAID, got %s by %q", repo.order.Status, repo.paidBy)
+	}
+}
+
+func TestPaymentWebhookRejectsBadSignature(t *testing.T) {
+	provider := payment.NewFakeProvider("webhook-secret")
+	repo := &webhookRepo{order: domain.Order{BookingID: "WT-TESTBOOKING", Status: domain.OrderStatusPending}}
+	app := newWebhookApp(t, provider, repo)
+
+	body := []byte(`{"booking_id":"WT-TESTBOOKING","payment_status":"PAID","gross_amount":"150000.00"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/payment", bytes.NewReader(body))
+	req.Header.Set(payment.FakeSignatureHeader, hex.EncodeToString(payment.NewFakeProvider("wrong").Sign(body)))
+
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", res.StatusCode)
+	}
+	if repo.order.Status != domain.OrderStatusPending {
+		t.Fatalf("order must stay PENDING, got %s", repo.order.Status)
+	}
+}
@@ -1,11 +1,15 @@
 package app
 
 import (
+	"context"
 	"fmt"
 	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/platform/cache"
 	"go-war-ticket-service/internal/platform/database"
 	"go-war-ticket-service/internal/platform/storage"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -13,6 +17,8 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 type Server struct {
 	app *fiber.App
 	cfg configs.Config
@@ -54,8 +60,15 @@ func (s *Server) Start() error {
 	}
 	s.log.Info("minio (s3) connection established")
 
+	// Cancelled on SIGINT/SIGTERM so workers stop taking new jobs
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
 	// Setup Dependencies
-	deps := SetupDependencies(s.cfg, s.log, db, rdb, s3Client)
+	deps, err := SetupDependencies(ctx, s.cfg, s.log, db, rdb, s3Client)
+	if err != nil {
+		return fmt.Errorf("failed to set up dependencies: %w", err)
+	}
 
 	// Setup Routes
 	SetupRoutes(s.app, deps)
@@ -63,5 +76,29 @@ func (s *Server) Start() error {
 	// Start server
 	addr := fmt.Sprintf("%s:%d", s.cfg.ServerHost, s.cfg.ServerPort)
 	s.log.Infof("starting server on %s", addr)
-	return s.app.Listen(addr)
+
+	timeout := s.cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+
+	go func() {
+		<-ctx.Done()
+		s.log.Info("shutting down, draining requests and workers")
+		if err := s.app.ShutdownWithTimeout(timeout); err != nil {
+			s.log.Warnf("server shutdown: %v", err)
+		}
+	}()
+
+	if err := s.app.Listen(addr); err != nil {
+		return err
+	}
+
+	// Listen also returns when the server is shut down
+	stop()
+	if err := deps.Shutdown(timeout); err != nil {
+		s.log.Warnf("shutdown: %v", err)
+	}
+	s.log.Info("server stopped")
+	return nil
 }
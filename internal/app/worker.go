@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/features/notification"
+	"go-war-ticket-service/internal/features/order"
+	"go-war-ticket-service/internal/features/ticket"
+	"go-war-ticket-service/internal/platform/database"
+	"go-war-ticket-service/internal/platform/idgen"
+	"go-war-ticket-service/internal/platform/mailer"
+	rabbitmq "go-war-ticket-service/internal/platform/message_broker/rabbit_mq"
+	"go-war-ticket-service/internal/platform/pdf"
+	"go-war-ticket-service/internal/platform/storage"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Workers are the queue consumers: ticket generation and ticket emails.
+// The API runs them in process unless DISABLE_EMBEDDED_WORKER is set, in
+// which case cmd/worker runs them on their own.
+type Workers struct {
+	wg sync.WaitGroup
+}
+
+// StartWorkers starts the queue consumers. They stop taking jobs once ctx is
+// cancelled, Wait tells when the jobs they held are done.
+func StartWorkers(
+	ctx context.Context,
+	cfg configs.Config,
+	log *zap.SugaredLogger,
+	db *gorm.DB,
+	s3 *minio.Client,
+	mq rabbitmq.Publisher,
+	qrSigner *ticket.QRSigner,
+	mail mailer.Mailer,
+) *Workers {
+	// Ticket Worker
+	ticketRepo := ticket.NewRepository(db)
+	orderRepo := order.NewRepository(db)
+	ticketWorker := ticket.NewTicketWorker(mq, ticketRepo, orderRepo, s3, cfg, pdf.NewMarotoGenerator(), qrSigner, idgen.NewCrockford(), log)
+
+	// Notification Worker
+	notificationRepo := notification.NewRepository(db)
+	notificationWorker := notification.NewNotificationWorker(mq, notificationRepo, mail, s3, cfg, log)
+
+	w := &Workers{}
+	for _, start := range []func(context.Context){ticketWorker.Start, notificationWorker.Start} {
+		w.wg.Add(1)
+		go func(start func(context.Context)) {
+			defer w.wg.Done()
+			start(ctx)
+		}(start)
+	}
+
+	return w
+}
+
+// Wait blocks until every worker has returned, or timeout passes
+func (w *Workers) Wait(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("workers still running after %s", timeout)
+	}
+}
+
+// WorkerServer runs only the queue workers, so PDF rendering scales apart
+// from request serving
+type WorkerServer struct {
+	cfg configs.Config
+	log *zap.SugaredLogger
+}
+
+func NewWorkerServer(cfg configs.Config, log *zap.SugaredLogger) *WorkerServer {
+	return &WorkerServer{
+		cfg: cfg,
+		log: log,
+	}
+}
+
+// Start runs the workers until SIGINT/SIGTERM, then lets them finish the
+// jobs in flight
+func (s *WorkerServer) Start() error {
+	// Database connection
+	db, err := database.Connect(s.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	s.log.Info("database connection established")
+
+	// Minio connection
+	s3Client, err := storage.NewMinIOClient(s.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to minio: %w", err)
+	}
+	s.log.Info("minio (s3) connection established")
+
+	// RabbitMQ connection
+	mqPublisher, err := rabbitmq.NewRabbitMQPublisher(s.cfg.RabbitMQURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+	defer mqPublisher.Close()
+	createQueues(mqPublisher)
+
+	// The API verifies what this process signs, so the key must be shared
+	qrSigner, err := newQRSigner(s.cfg, false, s.log)
+	if err != nil {
+		return fmt.Errorf("failed to load ticket QR private key: %w", err)
+	}
+
+	mail, err := newMailer(s.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create mailer: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	workers := StartWorkers(ctx, s.cfg, s.log, db, s3Client, mqPublisher, qrSigner, mail)
+	s.log.Info("workers started")
+
+	<-ctx.Done()
+	s.log.Info("shutting down, draining workers")
+
+	timeout := s.cfg.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	if err := workers.Wait(timeout); err != nil {
+		s.log.Warnf("shutdown: %v", err)
+	}
+
+	s.log.Info("worker stopped")
+	return nil
+}
@@ -5,6 +5,7 @@ import "errors"
 var (
 	// Common auth errors
 	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
 
 	// General errors
 	ErrNotFound        = errors.New("resource not found")
@@ -14,19 +15,70 @@ var (
 	ErrBadRequest      = errors.New("bad request")
 	ErrTooManyRequests = errors.New("too many requests")
 
+	// Idempotency errors
+	ErrInvalidIdempotencyKey        = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyMismatch       = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyRequestInProgress = errors.New("a request with this idempotency key is still being processed")
+
+	// Queue errors
+	ErrQueueNotFound = errors.New("queue not found")
+
 	// Specific errors
 	ErrEmailAlreadyExists    = errors.New("email already exists")
 	ErrUsernameAlreadyExists = errors.New("username already exists")
 	ErrUserNotFound          = errors.New("user not found")
 	ErrInvalidID             = errors.New("invalid id")
+	ErrInvalidRole           = errors.New("invalid role")
 
 	// Auth errors
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidCredentials   = errors.New("invalid credentials")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token reuse detected, please log in again")
+	ErrInvalidUserToken     = errors.New("invalid or expired token")
+	ErrEmailNotVerified     = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified = errors.New("email address is already verified")
 
 	// Event errors
 	ErrInvalidStock   = errors.New("invalid stock")
 	ErrInvalidPrice   = errors.New("invalid price")
+	ErrInvalidMoney   = errors.New("invalid money amount")
 	ErrInvalidDate    = errors.New("invalid date")
 	ErrEventNotFound  = errors.New("event not found")
 	ErrNotEnoughStock = errors.New("not enough stock")
+	ErrStockBelowSold = errors.New("total stock cannot be lower than tickets already sold")
+
+	// Ticket tier errors
+	ErrTierNotFound      = errors.New("ticket tier not found")
+	ErrTierNotOnSale     = errors.New("ticket tier is not on sale")
+	ErrTierLimitExceeded = errors.New("quantity exceeds the tier's per-order limit")
+	ErrTierRequired      = errors.New("event has several tiers, update price and stock per tier")
+
+	// Purchase limit errors
+	ErrPurchaseLimitExceeded = errors.New("purchase limit for this event reached")
+
+	// Waiting room errors
+	ErrNotInWaitingRoom  = errors.New("not in the waiting room for this event")
+	ErrAdmissionRequired = errors.New("a valid admission token from the waiting room is required")
+	ErrWaitingRoomClosed = errors.New("waiting room is not enabled")
+
+	// Order errors
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrOrderExpired           = errors.New("order has expired")
+	ErrOrderCancelled         = errors.New("order has been cancelled")
+	ErrInvalidOrderTransition = errors.New("invalid order status transition")
+	ErrOrderNotFailed         = errors.New("only failed orders can be requeued")
+
+	// Payment errors
+	ErrInvalidPaymentSignature = errors.New("invalid payment signature")
+	ErrPaymentAmountMismatch   = errors.New("payment amount does not match order total")
+	ErrPaymentUnavailable      = errors.New("payment provider unavailable")
+
+	// Ticket errors
+	ErrTicketNotFound     = errors.New("ticket not found")
+	ErrTicketAlreadyUsed  = errors.New("ticket already used")
+	ErrTicketRevoked      = errors.New("ticket has been revoked")
+	ErrTicketNotActive    = errors.New("ticket's order is not completed")
+	ErrInvalidTicketToken = errors.New("invalid ticket token")
+	ErrTicketTokenExpired = errors.New("ticket token expired")
+	ErrTicketsIncomplete  = errors.New("order does not have all of its tickets yet")
 )
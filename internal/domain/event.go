@@ -1,15 +1,48 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Event struct {
 	BaseModel
+	OrganiserID    uuid.UUID `gorm:"type:uuid;index" json:"organiser_id"` // user who owns and manages the event
 	Name           string    `gorm:"type:varchar(100);not null" json:"name"`
 	Location       string    `gorm:"type:text;not null" json:"location"`
 	Date           time.Time `gorm:"not null" json:"date"`
-	Price          float64   `gorm:"type:decimal(10,2);not null" json:"price"`
+	Price          Money     `gorm:"type:decimal(12,2);not null" json:"price"` // lowest tier price
 	Description    string    `gorm:"type:text" json:"description,omitempty"`
 	Image          string    `gorm:"type:text" json:"image,omitempty"`
-	TotalStock     int       `gorm:"not null" json:"total_stock"`
-	AvailableStock int       `gorm:"not null;check:available_stock <= total_stock" json:"available_stock"`
+	TotalStock     int       `gorm:"not null" json:"total_stock"`                                          // sum over tiers
+	AvailableStock int       `gorm:"not null;check:available_stock <= total_stock" json:"available_stock"` // sum over tiers
+
+	MaxTicketsPerUser int `gorm:"not null;default:0" json:"max_tickets_per_user"` // 0 falls back to ORDER_MAX_TICKETS_PER_USER
+
+	Tiers []TicketTier `gorm:"foreignKey:EventID;references:ID" json:"tiers,omitempty"`
+}
+
+// TicketTier is a priced category of seats for an event, e.g. VIP or Early-Bird
+type TicketTier struct {
+	BaseModel
+	EventID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"event_id"`
+	Name           string     `gorm:"type:varchar(50);not null" json:"name"`
+	Price          Money      `gorm:"type:decimal(12,2);not null" json:"price"`
+	TotalStock     int        `gorm:"not null" json:"total_stock"`
+	AvailableStock int        `gorm:"not null;check:available_stock <= total_stock" json:"available_stock"`
+	MaxPerOrder    int        `gorm:"not null;default:0" json:"max_per_order"` // 0 means no limit
+	SaleStartAt    *time.Time `json:"sale_start_at,omitempty"`
+	SaleEndAt      *time.Time `json:"sale_end_at,omitempty"`
+}
+
+// IsOnSale reports whether the tier's sale window is open at the given time
+func (t TicketTier) IsOnSale(now time.Time) bool {
+	if t.SaleStartAt != nil && now.Before(*t.SaleStartAt) {
+		return false
+	}
+	if t.SaleEndAt != nil && now.After(*t.SaleEndAt) {
+		return false
+	}
+	return true
 }
@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is a fixed-point amount stored in hundredths of the currency unit,
+// so 150000.50 is Money(15000050). It maps to decimal(x,2) columns and to
+// plain JSON numbers, and never goes through float64 arithmetic.
+type Money int64
+
+const moneyScale = 100
+
+// NewMoneyFromFloat rounds a float to the nearest hundredth. Only use it at
+// the edges (config, legacy inputs), never for arithmetic.
+func NewMoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * moneyScale))
+}
+
+// ParseMoney parses a decimal string such as "150000", "150000.5" or
+// "150000.00". Digits past the second decimal place must be zero.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, ErrInvalidMoney
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return 0, ErrInvalidMoney
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	if len(frac) > 2 {
+		if strings.Trim(frac[2:], "0") != "" {
+			return 0, ErrInvalidMoney
+		}
+		frac = frac[:2]
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	units, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || units < 0 {
+		return 0, ErrInvalidMoney
+	}
+	cents, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil || cents < 0 {
+		return 0, ErrInvalidMoney
+	}
+	if units > (math.MaxInt64-cents)/moneyScale {
+		return 0, ErrInvalidMoney
+	}
+
+	amount := Money(units*moneyScale + cents)
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// Mul multiplies the amount by a quantity
+func (m Money) Mul(qty int) Money {
+	return m * Money(qty)
+}
+
+// Float64 is for handing the amount to APIs that only take floats
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+// String formats the amount with exactly two decimal places
+func (m Money) String() string {
+	sign := ""
+	abs := int64(m)
+	if abs < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/moneyScale, abs%moneyScale)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts both JSON numbers and numeric strings
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := strings.Trim(string(data), `"`)
+	if raw == "null" {
+		return nil
+	}
+
+	amount, err := ParseMoney(raw)
+	if err != nil {
+		return err
+	}
+	*m = amount
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		amount, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = amount
+		return nil
+	case string:
+		amount, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = amount
+		return nil
+	case int64:
+		*m = Money(v * moneyScale)
+		return nil
+	case float64:
+		*m = NewMoneyFromFloat(v)
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		err  bool
+	}{
+		{in: "150000", want: 15000000},
+		{in: "150000.5", want: 15000050},
+		{in: "150000.50", want: 15000050},
+		{in: "150000.500", want: 15000050},
+		{in: " 0.01 ", want: 1},
+		{in: ".5", want: 50},
+		{in: "-12.30", want: -1230},
+		{in: "+7", want: 700},
+		{in: "150000.505", err: true},
+		{in: "", err: true},
+		{in: ".", err: true},
+		{in: "abc", err: true},
+		{in: "1.-5", err: true},
+		{in: "92233720368547758.08", err: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidMoney) {
+				t.Errorf("ParseMoney(%q) = %v, %v, want ErrInvalidMoney", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := map[Money]string{
+		0:        "0.00",
+		1:        "0.01",
+		15000050: "150000.50",
+		-1230:    "-12.30",
+	}
+
+	for m, want := range tests {
+		if got := m.String(); got != want {
+			t.Errorf("Money(%d).String() = %q, want %q", int64(m), got, want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		Number Money `json:"number"`
+		Text   Money `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(`{"number": 1500.25, "text": "99.9"}`), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.Number != 150025 || v.Text != 9990 {
+		t.Fatalf("got %d and %d", v.Number, v.Text)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(out) != `{"number":1500.25,"text":99.90}` {
+		t.Fatalf("marshal = %s", out)
+	}
+}
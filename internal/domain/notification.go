@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EmailKind string
+
+const (
+	EmailKindTicketsReady EmailKind = "tickets_ready"
+)
+
+type EmailDeliveryStatus string
+
+const (
+	EmailDeliveryPending EmailDeliveryStatus = "PENDING"
+	EmailDeliverySent    EmailDeliveryStatus = "SENT"
+	EmailDeliveryFailed  EmailDeliveryStatus = "FAILED"
+)
+
+// EmailDelivery logs every transactional email sent for an order. There is
+// one row per order and kind, so a redelivered message doesn't email twice.
+type EmailDelivery struct {
+	BaseModel
+	OrderID   uuid.UUID           `gorm:"type:uuid;not null;uniqueIndex:idx_email_deliveries_order_kind" json:"order_id"`
+	Kind      EmailKind           `gorm:"type:varchar(30);not null;uniqueIndex:idx_email_deliveries_order_kind" json:"kind"`
+	UserID    uuid.UUID           `gorm:"type:uuid;not null;index" json:"user_id"`
+	Recipient string              `gorm:"type:varchar(100);not null" json:"recipient"`
+	Status    EmailDeliveryStatus `gorm:"type:varchar(20);not null;default:'PENDING';index" json:"status"`
+	Attempts  int                 `gorm:"not null;default:0" json:"attempts"`
+	LastError string              `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt    *time.Time          `json:"sent_at,omitempty"`
+}
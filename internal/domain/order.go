@@ -1,6 +1,10 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type OrderStatus string
 
@@ -10,18 +14,54 @@ const (
 	OrderStatusProcessing OrderStatus = "PROCESSING"
 	OrderStatusCompleted  OrderStatus = "COMPLETED"
 	OrderStatusFailed     OrderStatus = "FAILED"
+	OrderStatusExpired    OrderStatus = "EXPIRED"
+	OrderStatusCancelled  OrderStatus = "CANCELLED"
+	OrderStatusRefunding  OrderStatus = "REFUNDING"
+	OrderStatusRefunded   OrderStatus = "REFUNDED"
 )
 
 type Order struct {
 	BaseModel
-	BookingID  string      `gorm:"type:varchar(25);not null;uniqueIndex" json:"booking_id"`
-	UserID     uuid.UUID   `gorm:"not null" json:"user_id"`
-	EventID    uuid.UUID   `gorm:"not null" json:"event_id"`
-	Quantity   int         `gorm:"not null" json:"quantity"`
-	// TotalPrice float64     `gorm:"type:decimal(10,2);not null" json:"total_price"`
-	Status     OrderStatus `gorm:"type:varchar(50);default:'PENDING';index" json:"status"`
-
-	User   User     `gorm:"foreignKey:UserID;references:ID" json:"user"`
-	Event  Event    `gorm:"foreignKey:EventID;references:ID" json:"event"`
-	Ticket []Ticket `gorm:"foreignKey:OrderID;references:ID" json:"ticket"`
+	BookingID string    `gorm:"type:varchar(25);not null;uniqueIndex" json:"booking_id"`
+	UserID    uuid.UUID `gorm:"not null" json:"user_id"`
+	EventID   uuid.UUID `gorm:"not null" json:"event_id"`
+	TierID    uuid.UUID `gorm:"type:uuid;index" json:"tier_id"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+
+	// Amounts are captured at purchase time and never recomputed from the
+	// event or tier, so later price edits don't rewrite order history
+	UnitPrice  Money  `gorm:"type:decimal(12,2);not null;default:0" json:"unit_price"`
+	Subtotal   Money  `gorm:"type:decimal(12,2);not null;default:0" json:"subtotal"` // unit price x quantity
+	Fee        Money  `gorm:"type:decimal(12,2);not null;default:0" json:"fee"`
+	Discount   Money  `gorm:"type:decimal(12,2);not null;default:0" json:"discount"`
+	TotalPrice Money  `gorm:"type:decimal(12,2);not null;default:0" json:"total_price"` // subtotal + fee - discount
+	Currency   string `gorm:"type:varchar(3);not null;default:'IDR'" json:"currency"`
+
+	Status    OrderStatus `gorm:"type:varchar(50);default:'PENDING';index" json:"status"`
+	ExpiresAt *time.Time  `gorm:"index" json:"expires_at,omitempty"` // reservation deadline for PENDING orders
+	// FailureReason says why ticket generation gave up, set while FAILED
+	FailureReason string `gorm:"type:text" json:"failure_reason,omitempty"`
+
+	PaymentProvider  string `gorm:"type:varchar(30)" json:"payment_provider,omitempty"`
+	PaymentReference string `gorm:"type:varchar(100)" json:"payment_reference,omitempty"`
+	PaymentURL       string `gorm:"type:text" json:"payment_url,omitempty"`
+
+	User   User       `gorm:"foreignKey:UserID;references:ID" json:"user"`
+	Event  Event      `gorm:"foreignKey:EventID;references:ID" json:"event"`
+	Tier   TicketTier `gorm:"foreignKey:TierID;references:ID" json:"tier"`
+	Ticket []Ticket   `gorm:"foreignKey:OrderID;references:ID" json:"ticket"`
+}
+
+// OrderStatusHistory is an append-only log of every status change on an order
+type OrderStatusHistory struct {
+	BaseModel
+	OrderID    uuid.UUID   `gorm:"type:uuid;not null;index" json:"order_id"`
+	FromStatus OrderStatus `gorm:"type:varchar(50);not null" json:"from_status"`
+	ToStatus   OrderStatus `gorm:"type:varchar(50);not null" json:"to_status"`
+	Actor      string      `gorm:"type:varchar(100);not null" json:"actor"`
+	Reason     string      `gorm:"type:text" json:"reason,omitempty"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
 }
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "PENDING"
+	OutboxSent    OutboxStatus = "SENT"
+)
+
+// OutboxMessage is a queue message saved in the same transaction as the
+// change it announces. The outbox relay publishes it once that transaction
+// has committed, so the change and the message never go out of step.
+type OutboxMessage struct {
+	BaseModel
+	Queue       string       `gorm:"type:varchar(100);not null" json:"queue"`
+	Payload     string       `gorm:"type:jsonb;not null" json:"payload"`
+	Status      OutboxStatus `gorm:"type:varchar(20);not null;default:'PENDING';index:idx_outbox_messages_pending,priority:1" json:"status"`
+	AvailableAt time.Time    `gorm:"not null;index:idx_outbox_messages_pending,priority:2" json:"available_at"` // not published before this
+	Attempts    int          `gorm:"not null;default:0" json:"attempts"`
+	LastError   string       `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt      *time.Time   `json:"sent_at,omitempty"`
+}
+
+// NewOutboxMessage encodes payload as JSON for the given queue
+func NewOutboxMessage(queue string, payload interface{}) (OutboxMessage, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return OutboxMessage{}, err
+	}
+
+	return OutboxMessage{
+		Queue:       queue,
+		Payload:     string(body),
+		Status:      OutboxPending,
+		AvailableAt: time.Now(),
+	}, nil
+}
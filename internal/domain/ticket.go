@@ -1,21 +1,49 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type TicketStatus string
 
 const (
-	TicketStatusValid TicketStatus = "VALID"
-	TicketStatusUsed  TicketStatus = "USED"
+	TicketStatusValid   TicketStatus = "VALID"
+	TicketStatusUsed    TicketStatus = "USED"
+	TicketStatusRevoked TicketStatus = "REVOKED" // order was refunded or cancelled
+)
+
+type TicketScanResult string
+
+const (
+	TicketScanAccepted TicketScanResult = "ACCEPTED"
+	TicketScanRejected TicketScanResult = "REJECTED"
 )
 
 type Ticket struct {
 	BaseModel
-	OrderID uuid.UUID `gorm:"not null" json:"order_id"`
+	OrderID uuid.UUID `gorm:"not null;uniqueIndex:idx_ticket_order_seq,where:seq > 0" json:"order_id"`
 	EventID uuid.UUID `gorm:"not null" json:"event_id"`
 	UserID  uuid.UUID `gorm:"not null" json:"user_id"`
+	// Seq is the ticket's slot within its order, 1..Quantity. Tickets issued
+	// before slots existed keep 0 and stay out of the unique index.
+	Seq int `gorm:"not null;default:0;uniqueIndex:idx_ticket_order_seq,where:seq > 0" json:"seq"`
 
 	TicketNumber string       `gorm:"type:varchar(50);not null;uniqueIndex" json:"ticket_number"`
 	PDFUrl       string       `gorm:"type:text" json:"pdf_url"`
-	// Status       TicketStatus `gorm:"type:varchar(50);default:'VALID';index" json:"status"` // VALID, USED
+	Status       TicketStatus `gorm:"type:varchar(50);default:'VALID';index" json:"status"` // VALID, USED, REVOKED
+	UsedAt       *time.Time   `json:"used_at,omitempty"`
+	UsedByGate   string       `gorm:"type:varchar(50)" json:"used_by_gate,omitempty"`
+}
+
+// TicketScan records every check-in attempt made at a gate, accepted or not
+type TicketScan struct {
+	BaseModel
+	TicketID  uuid.UUID        `gorm:"type:uuid;not null;index" json:"ticket_id"`
+	GateID    string           `gorm:"type:varchar(50);not null" json:"gate_id"`
+	ScannedBy uuid.UUID        `gorm:"type:uuid" json:"scanned_by"`
+	Result    TicketScanResult `gorm:"type:varchar(20);not null" json:"result"`
+	Reason    string           `gorm:"type:text" json:"reason,omitempty"`
+	ScannedAt time.Time        `gorm:"not null" json:"scanned_at"`
 }
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is one link in a rotation chain. Only a hash of the token is
+// stored. Every token issued from the same login shares a FamilyID, so
+// presenting an already rotated token can revoke the whole chain.
+type RefreshToken struct {
+	BaseModel
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	TokenHash    string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID *uuid.UUID `gorm:"type:uuid" json:"replaced_by_id,omitempty"`
+}
+
+type UserTokenPurpose string
+
+const (
+	UserTokenEmailVerification UserTokenPurpose = "email_verification"
+	UserTokenPasswordReset     UserTokenPurpose = "password_reset"
+)
+
+// UserToken is a single-use, expiring token sent to the user by email.
+// Like refresh tokens only a hash is stored.
+type UserToken struct {
+	BaseModel
+	UserID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Purpose   UserTokenPurpose `gorm:"type:varchar(30);not null" json:"purpose"`
+	TokenHash string           `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time        `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time       `json:"used_at,omitempty"`
+}
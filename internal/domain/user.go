@@ -1,10 +1,44 @@
 package domain
 
+import "time"
+
+type UserRole string
+
+const (
+	RoleCustomer  UserRole = "customer"
+	RoleOrganiser UserRole = "organiser"
+	RoleAdmin     UserRole = "admin"
+	RoleGateStaff UserRole = "gate_staff"
+)
+
+// IsValid reports whether r is one of the known roles
+func (r UserRole) IsValid() bool {
+	return r.OneOf(RoleCustomer, RoleOrganiser, RoleAdmin, RoleGateStaff)
+}
+
+// OneOf reports whether r is any of the given roles
+func (r UserRole) OneOf(roles ...UserRole) bool {
+	for _, role := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type User struct {
 	BaseModel
-	FullName string `gorm:"type:varchar(100);not null" json:"full_name"`
-	Username string `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
-	Email    string `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
-	Password string `gorm:"type:varchar(255);not null" json:"-"`
-	Avatar   string `gorm:"type:text" json:"avatar,omitempty"`
+	FullName string   `gorm:"type:varchar(100);not null" json:"full_name"`
+	Username string   `gorm:"type:varchar(50);uniqueIndex;not null" json:"username"`
+	Email    string   `gorm:"type:varchar(100);uniqueIndex;not null" json:"email"`
+	Password string   `gorm:"type:varchar(255);not null" json:"-"`
+	Avatar   string   `gorm:"type:text" json:"avatar,omitempty"`
+	Role     UserRole `gorm:"type:varchar(20);not null;default:'customer';index" json:"role"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+}
+
+// IsEmailVerified reports whether the user confirmed they own their email
+func (u User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
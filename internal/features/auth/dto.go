@@ -15,15 +15,40 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8,max=100"`
+}
+
 type UserLoginResponse struct {
 	ID       uuid.UUID `json:"id"`
 	FullName string    `json:"full_name"`
 	Email    string    `json:"email"`
 	Avatar   string    `json:"avatar"`
+	Role     string    `json:"role"`
+
+	EmailVerified bool `json:"email_verified"`
 }
 
 type LoginResponse struct {
 	AccessToken  string            `json:"access_token"`
 	RefreshToken string            `json:"refresh_token"`
+	ExpiresIn    int64             `json:"expires_in"` // access token lifetime in seconds
 	User         UserLoginResponse `json:"user"`
 }
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/mailer"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func verificationEmail(user *domain.User, baseURL, token string, ttl time.Duration) mailer.Message {
+	link := emailLink(baseURL, "/verify-email", token)
+
+	return mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your TiBo email address",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\n"+
+				"The link expires in %s. If you didn't create a TiBo account you can ignore this email.\n",
+			user.FullName, link, ttl,
+		),
+	}
+}
+
+func passwordResetEmail(user *domain.User, baseURL, token string, ttl time.Duration) mailer.Message {
+	link := emailLink(baseURL, "/reset-password", token)
+
+	return mailer.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your TiBo password",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s\n\n"+
+				"The link expires in %s and can only be used once. If you didn't ask for this you can ignore this email.\n",
+			user.FullName, link, ttl,
+		),
+	}
+}
+
+func emailLink(baseURL, path, token string) string {
+	return strings.TrimRight(baseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
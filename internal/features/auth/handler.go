@@ -56,3 +56,99 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 
 	return responses.Success(c, res, "login successful")
 }
+
+func (h *Handler) Refresh(c *fiber.Ctx) error {
+	var req RefreshRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validate.Validate(&req); err != nil {
+		errors := h.validate.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	res, err := h.usecase.Refresh(c.Context(), req)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, res, "token refreshed")
+}
+
+func (h *Handler) Logout(c *fiber.Ctx) error {
+	var req LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return responses.Error(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	if err := h.usecase.Logout(c.Context(), req); err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, nil, "logout successful")
+}
+
+func (h *Handler) VerifyEmail(c *fiber.Ctx) error {
+	var req VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validate.Validate(&req); err != nil {
+		errors := h.validate.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	if err := h.usecase.VerifyEmail(c.Context(), req); err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, nil, "email verified")
+}
+
+func (h *Handler) ResendVerificationEmail(c *fiber.Ctx) error {
+	if err := h.usecase.ResendVerificationEmail(c.Context()); err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, nil, "verification email sent")
+}
+
+func (h *Handler) ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validate.Validate(&req); err != nil {
+		errors := h.validate.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	if err := h.usecase.ForgotPassword(c.Context(), req); err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, nil, "if the email is registered, a reset link has been sent")
+}
+
+func (h *Handler) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validate.Validate(&req); err != nil {
+		errors := h.validate.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	if err := h.usecase.ResetPassword(c.Context(), req); err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, nil, "password has been reset")
+}
@@ -2,11 +2,28 @@ package auth
 
 import (
 	"context"
+	"go-war-ticket-service/internal/domain"
+
+	"github.com/google/uuid"
 )
 
 type Usecase interface {
 	Register(ctx context.Context, req RegisterRequest) (*LoginResponse, error)
 	Login(ctx context.Context, req LoginRequest) (*LoginResponse, error)
+	Refresh(ctx context.Context, req RefreshRequest) (*LoginResponse, error)
+	Logout(ctx context.Context, req LogoutRequest) error
+	VerifyEmail(ctx context.Context, req VerifyEmailRequest) error
+	ResendVerificationEmail(ctx context.Context) error
+	ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req ResetPasswordRequest) error
 }
 
-type Repository interface{}
+type Repository interface {
+	CreateRefreshToken(ctx context.Context, token domain.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, tokenHash string, next domain.RefreshToken) (*domain.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error
+	CreateUserToken(ctx context.Context, token domain.UserToken) error
+	VerifyEmail(ctx context.Context, tokenHash string) (*domain.User, error)
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*domain.User, error)
+}
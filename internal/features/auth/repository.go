@@ -1,7 +1,13 @@
 package auth
 
 import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repository struct {
@@ -13,3 +19,176 @@ func NewRepository(db *gorm.DB) Repository {
 		db: db,
 	}
 }
+
+func (r *repository) CreateRefreshToken(ctx context.Context, token domain.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(&token).Error
+}
+
+func (r *repository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RotateRefreshToken swaps the presented token for next, which joins the same
+// family. Presenting a token that was already rotated or revoked means it was
+// copied, so the whole family is revoked and ErrRefreshTokenReused returned.
+func (r *repository) RotateRefreshToken(ctx context.Context, tokenHash string, next domain.RefreshToken) (*domain.RefreshToken, error) {
+	var current domain.RefreshToken
+	reused := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", tokenHash).
+			First(&current).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrInvalidRefreshToken
+			}
+			return err
+		}
+
+		now := time.Now()
+
+		if current.RevokedAt != nil {
+			// Commit the family revocation, the error is returned afterwards
+			reused = true
+			return revokeFamily(tx, current.FamilyID, now)
+		}
+
+		if now.After(current.ExpiresAt) {
+			return domain.ErrInvalidRefreshToken
+		}
+
+		next.UserID = current.UserID
+		next.FamilyID = current.FamilyID
+		if err := tx.Create(&next).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"replaced_by_id": next.ID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return nil, domain.ErrRefreshTokenReused
+	}
+
+	return &next, nil
+}
+
+func (r *repository) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	return revokeFamily(r.db.WithContext(ctx), familyID, time.Now())
+}
+
+func revokeFamily(db *gorm.DB, familyID uuid.UUID, now time.Time) error {
+	return db.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", now).Error
+}
+
+// CreateUserToken saves a new email token. Any unused token the user has for
+// the same purpose stops working, so only the latest link is valid.
+func (r *repository) CreateUserToken(ctx context.Context, token domain.UserToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, token.Purpose).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&token).Error
+	})
+}
+
+func (r *repository) VerifyEmail(ctx context.Context, tokenHash string) (*domain.User, error) {
+	var user domain.User
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, tokenHash, domain.UserTokenEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, "id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(&user).Update("email_verified_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// ResetPassword sets the new password and signs the user out everywhere by
+// revoking all of their refresh tokens
+func (r *repository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*domain.User, error) {
+	var user domain.User
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, tokenHash, domain.UserTokenPasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, "id = ?", token.UserID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Update("password", passwordHash).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// consumeUserToken marks a valid token as used so it can't be replayed
+func consumeUserToken(tx *gorm.DB, tokenHash string, purpose domain.UserTokenPurpose) (*domain.UserToken, error) {
+	var token domain.UserToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", tokenHash, purpose).
+		First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, domain.ErrInvalidUserToken
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil || now.After(token.ExpiresAt) {
+		return nil, domain.ErrInvalidUserToken
+	}
+
+	if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
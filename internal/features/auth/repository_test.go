@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestRepository runs the repository against an in-memory SQLite
+// database. SQLite has no row locks, so this covers the rotation logic but
+// not concurrent refreshes.
+func newTestRepository(t *testing.T) (*repository, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&domain.RefreshToken{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return &repository{db: db}, db
+}
+
+func issueToken(t *testing.T, r *repository, hash string) domain.RefreshToken {
+	t.Helper()
+
+	token := domain.RefreshToken{
+		UserID:    uuid.New(),
+		FamilyID:  uuid.New(),
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := r.CreateRefreshToken(context.Background(), token); err != nil {
+		t.Fatalf("create refresh token: %v", err)
+	}
+	return token
+}
+
+func nextToken(hash string) domain.RefreshToken {
+	return domain.RefreshToken{TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
+}
+
+func TestRotateRefreshTokenKeepsFamily(t *testing.T) {
+	ctx := context.Background()
+	r, _ := newTestRepository(t)
+	first := issueToken(t, r, "hash-1")
+
+	second, err := r.RotateRefreshToken(ctx, "hash-1", nextToken("hash-2"))
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if second.UserID != first.UserID || second.FamilyID != first.FamilyID {
+		t.Fatalf("expected the new token to join the family, got %+v", second)
+	}
+
+	old, err := r.GetRefreshTokenByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("get old token: %v", err)
+	}
+	if old.RevokedAt == nil || old.ReplacedByID == nil || *old.ReplacedByID != second.ID {
+		t.Fatalf("expected the old token revoked and pointing at its replacement, got %+v", old)
+	}
+
+	if _, err := r.RotateRefreshToken(ctx, "hash-2", nextToken("hash-3")); err != nil {
+		t.Fatalf("expected the new token to rotate in turn: %v", err)
+	}
+}
+
+func TestRotateRefreshTokenDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	r, db := newTestRepository(t)
+	first := issueToken(t, r, "hash-1")
+
+	if _, err := r.RotateRefreshToken(ctx, "hash-1", nextToken("hash-2")); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	// The rotated token shows up again, someone copied it
+	if _, err := r.RotateRefreshToken(ctx, "hash-1", nextToken("hash-3")); !errors.Is(err, domain.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	// The whole family is revoked, including the legitimate latest token
+	var live int64
+	if err := db.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", first.FamilyID).
+		Count(&live).Error; err != nil {
+		t.Fatalf("count live tokens: %v", err)
+	}
+	if live != 0 {
+		t.Fatalf("expected the family to be revoked, %d tokens still live", live)
+	}
+	if _, err := r.RotateRefreshToken(ctx, "hash-2", nextToken("hash-4")); !errors.Is(err, domain.ErrRefreshTokenReused) {
+		t.Fatalf("expected the latest token to be revoked too, got %v", err)
+	}
+
+	// Nothing was issued for the reused token
+	if token, err := r.GetRefreshTokenByHash(ctx, "hash-3"); err != nil || token != nil {
+		t.Fatalf("expected no token issued on reuse, got %+v, %v", token, err)
+	}
+}
+
+func TestRotateRefreshTokenRejectsUnknownAndExpired(t *testing.T) {
+	ctx := context.Background()
+	r, db := newTestRepository(t)
+
+	if _, err := r.RotateRefreshToken(ctx, "missing", nextToken("hash-2")); !errors.Is(err, domain.ErrInvalidRefreshToken) {
+		t.Fatalf("unknown token: expected ErrInvalidRefreshToken, got %v", err)
+	}
+
+	expired := issueToken(t, r, "hash-expired")
+	if err := db.Model(&domain.RefreshToken{}).Where("token_hash = ?", expired.TokenHash).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatalf("expire token: %v", err)
+	}
+	if _, err := r.RotateRefreshToken(ctx, "hash-expired", nextToken("hash-3")); !errors.Is(err, domain.ErrInvalidRefreshToken) {
+		t.Fatalf("expired token: expected ErrInvalidRefreshToken, got %v", err)
+	}
+}
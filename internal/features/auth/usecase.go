@@ -2,16 +2,20 @@ package auth
 
 import (
 	"context"
+	"fmt"
 	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/domain"
 	"go-war-ticket-service/internal/features/user"
+	"go-war-ticket-service/internal/platform/mailer"
 	"go-war-ticket-service/internal/platform/storage"
 	"go-war-ticket-service/internal/utils"
+	"go-war-ticket-service/internal/utils/contextutil"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
@@ -23,9 +27,16 @@ type Hasher interface {
 
 // TokenGenerator defines methods for generating tokens.
 type TokenGenerator interface {
-	GenerateToken(userID uuid.UUID) (string, error)
+	GenerateToken(userID uuid.UUID, role domain.UserRole) (string, error)
+	AccessTTL() time.Duration
 }
 
+const (
+	defaultRefreshTokenTTL           = 30 * 24 * time.Hour
+	defaultEmailVerificationTokenTTL = 24 * time.Hour
+	defaultPasswordResetTokenTTL     = time.Hour
+)
+
 type usecase struct {
 	repo        Repository
 	userRepo    user.Repository
@@ -34,6 +45,9 @@ type usecase struct {
 	log         *zap.SugaredLogger
 	minioClient *minio.Client
 	cfg         configs.Config
+	cache       *redis.Client
+	mailer      mailer.Mailer
+	refreshTTL  time.Duration
 }
 
 func NewUsecase(
@@ -44,15 +58,28 @@ func NewUsecase(
 	log *zap.SugaredLogger,
 	minioClient *minio.Client,
 	cfg configs.Config,
+	cache *redis.Client,
+	mail mailer.Mailer,
 ) Usecase {
+	log = log.Named("AuthUsecase")
+
+	refreshTTL, err := utils.ParseDuration(cfg.JWTRefreshTokenExpire)
+	if err != nil || refreshTTL <= 0 {
+		log.Warnf("invalid JWT_REFRESH_TOKEN_EXPIRE %q, using %s", cfg.JWTRefreshTokenExpire, defaultRefreshTokenTTL)
+		refreshTTL = defaultRefreshTokenTTL
+	}
+
 	return &usecase{
 		repo:        r,
 		userRepo:    ur,
 		hasher:      h,
 		jwt:         j,
-		log:         log.Named("AuthUsecase"),
+		log:         log,
 		minioClient: minioClient,
 		cfg:         cfg,
+		cache:       cache,
+		mailer:      mail,
+		refreshTTL:  refreshTTL,
 	}
 }
 
@@ -103,6 +130,7 @@ func (u *usecase) Register(ctx context.Context, req RegisterRequest) (*LoginResp
 		Username: username,
 		Password: hashedPass,
 		Avatar:   req.Avatar,
+		Role:     domain.RoleCustomer,
 	}
 
 	createdUser, err := u.userRepo.CreateUser(ctx, newUser)
@@ -111,8 +139,13 @@ func (u *usecase) Register(ctx context.Context, req RegisterRequest) (*LoginResp
 		return nil, domain.ErrInternal
 	}
 
+	// A failed email is not fatal, the user can ask for another one
+	if err := u.sendUserToken(ctx, createdUser, domain.UserTokenEmailVerification); err != nil {
+		u.log.Errorf("failed to send verification email to user %s: %v", createdUser.ID, err)
+	}
+
 	// Return login response
-	return u.generateLoginResponse(createdUser)
+	return u.startSession(ctx, createdUser)
 }
 
 func (u *usecase) Login(ctx context.Context, req LoginRequest) (*LoginResponse, error) {
@@ -135,11 +168,233 @@ func (u *usecase) Login(ctx context.Context, req LoginRequest) (*LoginResponse,
 	}
 
 	// Generate login response
-	return u.generateLoginResponse(user)
+	return u.startSession(ctx, user)
+}
+
+// Refresh trades a refresh token for a new access and refresh token pair.
+// The user is reloaded so role changes apply from the next refresh.
+func (u *usecase) Refresh(ctx context.Context, req RefreshRequest) (*LoginResponse, error) {
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		u.log.Error("failed to generate refresh token", zap.Error(err))
+		return nil, domain.ErrInternal
+	}
+
+	rotated, err := u.repo.RotateRefreshToken(ctx, hashToken(req.RefreshToken, u.cfg.JWTRefreshSecret), domain.RefreshToken{
+		TokenHash: hashToken(refreshToken, u.cfg.JWTRefreshSecret),
+		ExpiresAt: time.Now().Add(u.refreshTTL),
+	})
+	if err != nil {
+		switch err {
+		case domain.ErrInvalidRefreshToken:
+			return nil, err
+		case domain.ErrRefreshTokenReused:
+			u.log.Warnf("refresh token reuse detected, revoked its token family")
+			return nil, err
+		}
+		u.log.Error("failed to rotate refresh token", zap.Error(err))
+		return nil, domain.ErrInternal
+	}
+
+	user, err := u.userRepo.GetUserByID(ctx, rotated.UserID)
+	if err != nil {
+		u.log.Error("failed to get user by ID: ", err)
+		return nil, domain.ErrInternal
+	}
+
+	if user == nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	return u.generateLoginResponse(user, refreshToken)
+}
+
+// Logout revokes the access token used for the request and, when given, the
+// refresh token family it was issued with
+func (u *usecase) Logout(ctx context.Context, req LogoutRequest) error {
+	if jti, err := contextutil.GetTokenID(ctx); err == nil {
+		ttl := u.jwt.AccessTTL()
+		if exp, err := contextutil.GetTokenExpiresAt(ctx); err == nil {
+			ttl = time.Until(exp)
+		}
+
+		if ttl > 0 {
+			if err := u.cache.Set(ctx, fmt.Sprintf(utils.AccessTokenDenylistKey, jti), 1, ttl).Err(); err != nil {
+				u.log.Error("failed to revoke access token", zap.Error(err))
+				return domain.ErrInternal
+			}
+		}
+	}
+
+	if req.RefreshToken == "" {
+		return nil
+	}
+
+	token, err := u.repo.GetRefreshTokenByHash(ctx, hashToken(req.RefreshToken, u.cfg.JWTRefreshSecret))
+	if err != nil {
+		u.log.Error("failed to get refresh token", zap.Error(err))
+		return domain.ErrInternal
+	}
+
+	currentUserID, _ := contextutil.GetUserID(ctx)
+	if token == nil || token.UserID != currentUserID {
+		return domain.ErrInvalidRefreshToken
+	}
+
+	if err := u.repo.RevokeRefreshTokenFamily(ctx, token.FamilyID); err != nil {
+		u.log.Error("failed to revoke refresh token family", zap.Error(err))
+		return domain.ErrInternal
+	}
+
+	return nil
+}
+
+func (u *usecase) VerifyEmail(ctx context.Context, req VerifyEmailRequest) error {
+	user, err := u.repo.VerifyEmail(ctx, hashToken(req.Token, u.cfg.JWTRefreshSecret))
+	if err != nil {
+		if err == domain.ErrInvalidUserToken {
+			return err
+		}
+		u.log.Error("failed to verify email", zap.Error(err))
+		return domain.ErrInternal
+	}
+
+	u.log.Infof("user %s verified their email", user.ID)
+	return nil
+}
+
+func (u *usecase) ResendVerificationEmail(ctx context.Context) error {
+	userID, err := contextutil.GetUserID(ctx)
+	if err != nil {
+		return domain.ErrUnauthorized
+	}
+
+	user, err := u.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		u.log.Error("failed to get user by ID: ", err)
+		return domain.ErrInternal
+	}
+
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+
+	if user.IsEmailVerified() {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	if err := u.sendUserToken(ctx, user, domain.UserTokenEmailVerification); err != nil {
+		u.log.Errorf("failed to send verification email to user %s: %v", user.ID, err)
+		return domain.ErrInternal
+	}
+
+	return nil
+}
+
+// ForgotPassword emails a reset link. It succeeds for unknown emails too so
+// the endpoint can't be used to find out who has an account.
+func (u *usecase) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) error {
+	user, err := u.userRepo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		u.log.Error("failed to get user by email: ", err)
+		return domain.ErrInternal
+	}
+
+	if user == nil {
+		u.log.Infof("password reset requested for unknown email %s", req.Email)
+		return nil
+	}
+
+	if err := u.sendUserToken(ctx, user, domain.UserTokenPasswordReset); err != nil {
+		u.log.Errorf("failed to send password reset email to user %s: %v", user.ID, err)
+		return domain.ErrInternal
+	}
+
+	return nil
 }
 
-func (u *usecase) generateLoginResponse(user *domain.User) (*LoginResponse, error) {
-	token, err := u.jwt.GenerateToken(user.ID)
+func (u *usecase) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	hashedPass, err := u.hasher.HashPassword(req.Password)
+	if err != nil {
+		u.log.Error("failed to hash password: ", err)
+		return domain.ErrInternal
+	}
+
+	user, err := u.repo.ResetPassword(ctx, hashToken(req.Token, u.cfg.JWTRefreshSecret), hashedPass)
+	if err != nil {
+		if err == domain.ErrInvalidUserToken {
+			return err
+		}
+		u.log.Error("failed to reset password", zap.Error(err))
+		return domain.ErrInternal
+	}
+
+	u.log.Infof("user %s reset their password", user.ID)
+	return nil
+}
+
+// sendUserToken issues a single-use token for purpose and emails its link
+func (u *usecase) sendUserToken(ctx context.Context, user *domain.User, purpose domain.UserTokenPurpose) error {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := u.userTokenTTL(purpose)
+	if err := u.repo.CreateUserToken(ctx, domain.UserToken{
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token, u.cfg.JWTRefreshSecret),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return err
+	}
+
+	msg := verificationEmail(user, u.cfg.AppBaseURL, token, ttl)
+	if purpose == domain.UserTokenPasswordReset {
+		msg = passwordResetEmail(user, u.cfg.AppBaseURL, token, ttl)
+	}
+
+	return u.mailer.Send(ctx, msg)
+}
+
+func (u *usecase) userTokenTTL(purpose domain.UserTokenPurpose) time.Duration {
+	if purpose == domain.UserTokenPasswordReset {
+		if u.cfg.PasswordResetTokenTTL > 0 {
+			return u.cfg.PasswordResetTokenTTL
+		}
+		return defaultPasswordResetTokenTTL
+	}
+
+	if u.cfg.EmailVerificationTokenTTL > 0 {
+		return u.cfg.EmailVerificationTokenTTL
+	}
+	return defaultEmailVerificationTokenTTL
+}
+
+// startSession issues the first refresh token of a new token family
+func (u *usecase) startSession(ctx context.Context, user *domain.User) (*LoginResponse, error) {
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		u.log.Error("failed to generate refresh token", zap.Error(err))
+		return nil, domain.ErrInternal
+	}
+
+	if err := u.repo.CreateRefreshToken(ctx, domain.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		TokenHash: hashToken(refreshToken, u.cfg.JWTRefreshSecret),
+		ExpiresAt: time.Now().Add(u.refreshTTL),
+	}); err != nil {
+		u.log.Error("failed to save refresh token", zap.Error(err))
+		return nil, domain.ErrInternal
+	}
+
+	return u.generateLoginResponse(user, refreshToken)
+}
+
+func (u *usecase) generateLoginResponse(user *domain.User, refreshToken string) (*LoginResponse, error) {
+	token, err := u.jwt.GenerateToken(user.ID, user.Role)
 	if err != nil {
 		u.log.Error("failed to generate token", zap.Error(err))
 		return nil, domain.ErrInternal
@@ -169,7 +424,15 @@ func (u *usecase) generateLoginResponse(user *domain.User) (*LoginResponse, erro
 		FullName: user.FullName,
 		Email:    user.Email,
 		Avatar:   avatarUrl,
+		Role:     string(user.Role),
+
+		EmailVerified: user.IsEmailVerified(),
 	}
 
-	return &LoginResponse{User: resUser, AccessToken: token}, nil
+	return &LoginResponse{
+		User:         resUser,
+		AccessToken:  token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(u.jwt.AccessTTL().Seconds()),
+	}, nil
 }
@@ -0,0 +1,20 @@
+package deadletter
+
+import "time"
+
+type DeadLetterResponse struct {
+	MessageID      string    `json:"message_id"`
+	Attempts       int       `json:"attempts"`
+	LastError      string    `json:"last_error"`
+	DeadLetteredAt time.Time `json:"dead_lettered_at"`
+	Body           string    `json:"body"`
+}
+
+type ReplayRequest struct {
+	MessageIDs []string `json:"message_ids" validate:"omitempty,max=100"`
+	Limit      int      `json:"limit" validate:"min=0,max=100"`
+}
+
+type ReplayResponse struct {
+	Replayed int `json:"replayed"`
+}
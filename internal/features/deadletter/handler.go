@@ -0,0 +1,60 @@
+package deadletter
+
+import (
+	"go-war-ticket-service/internal/platform/responses"
+	"go-war-ticket-service/internal/platform/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	usecase   Usecase
+	validator *validator.Validator
+}
+
+func NewHandler(uc Usecase, validator *validator.Validator) *Handler {
+	return &Handler{
+		usecase:   uc,
+		validator: validator,
+	}
+}
+
+// ListDeadLetters shows the oldest dead letters of a queue, ?limit= caps them
+func (h *Handler) ListDeadLetters(c *fiber.Ctx) error {
+	letters, err := h.usecase.ListDeadLetters(c.Context(), c.Params("queue"), c.QueryInt("limit"))
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	response := make([]DeadLetterResponse, len(letters))
+	for i, letter := range letters {
+		response[i] = DeadLetterResponse{
+			MessageID:      letter.MessageID,
+			Attempts:       letter.Attempts,
+			LastError:      letter.LastError,
+			DeadLetteredAt: letter.DeadLetteredAt,
+			Body:           string(letter.Body),
+		}
+	}
+
+	return responses.Success(c, response, "success")
+}
+
+func (h *Handler) ReplayDeadLetters(c *fiber.Ctx) error {
+	var req ReplayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		errors := h.validator.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	replayed, err := h.usecase.ReplayDeadLetters(c.Context(), c.Params("queue"), req.MessageIDs, req.Limit)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, ReplayResponse{Replayed: replayed}, "success")
+}
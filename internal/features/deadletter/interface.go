@@ -0,0 +1,11 @@
+package deadletter
+
+import (
+	"context"
+	rabbitmq "go-war-ticket-service/internal/platform/message_broker/rabbit_mq"
+)
+
+type Usecase interface {
+	ListDeadLetters(ctx context.Context, queue string, limit int) ([]rabbitmq.DeadLetter, error)
+	ReplayDeadLetters(ctx context.Context, queue string, messageIDs []string, limit int) (int, error)
+}
@@ -0,0 +1,75 @@
+package deadletter
+
+import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+	rabbitmq "go-war-ticket-service/internal/platform/message_broker/rabbit_mq"
+	"go-war-ticket-service/internal/utils"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// managedQueues are the queues whose dead letters can be inspected
+var managedQueues = []string{
+	utils.QueueTicketGeneration,
+	utils.QueueOrderRefunded,
+	utils.QueueTicketsReady,
+}
+
+type usecase struct {
+	mq  rabbitmq.Publisher
+	log *zap.SugaredLogger
+}
+
+func NewUsecase(mq rabbitmq.Publisher, log *zap.SugaredLogger) Usecase {
+	return &usecase{
+		mq:  mq,
+		log: log.Named("DeadLetterUsecase"),
+	}
+}
+
+func (u *usecase) ListDeadLetters(ctx context.Context, queue string, limit int) ([]rabbitmq.DeadLetter, error) {
+	if !slices.Contains(managedQueues, queue) {
+		return nil, domain.ErrQueueNotFound
+	}
+
+	letters, err := u.mq.PeekDeadLetters(queue, clampLimit(limit))
+	if err != nil {
+		u.log.Errorf("failed to peek dead letters of %s: %v", queue, err)
+		return nil, domain.ErrInternal
+	}
+
+	return letters, nil
+}
+
+func (u *usecase) ReplayDeadLetters(ctx context.Context, queue string, messageIDs []string, limit int) (int, error) {
+	if !slices.Contains(managedQueues, queue) {
+		return 0, domain.ErrQueueNotFound
+	}
+
+	if limit <= 0 && len(messageIDs) > 0 {
+		limit = len(messageIDs)
+	}
+
+	replayed, err := u.mq.ReplayDeadLetters(ctx, queue, messageIDs, clampLimit(limit))
+	if err != nil {
+		u.log.Errorf("failed to replay dead letters of %s after %d: %v", queue, replayed, err)
+		return replayed, domain.ErrInternal
+	}
+
+	u.log.Infof("Replayed %d dead letters to %s", replayed, queue)
+	return replayed, nil
+}
+
+func clampLimit(limit int) int {
+	if limit <= 0 {
+		return defaultLimit
+	}
+	return min(limit, maxLimit)
+}
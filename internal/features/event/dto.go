@@ -1,31 +1,83 @@
 package event
 
 import (
+	"go-war-ticket-service/internal/domain"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type EventRequest struct {
-	Name        string    `json:"name" validate:"required"`
-	Description string    `json:"description" validate:"required"`
-	Location    string    `json:"location" validate:"required"`
-	Price       float64   `json:"price" validate:"required"`
-	TotalStock  int       `json:"total_stock" validate:"required"`
-	Image       string    `json:"image" validate:"required"`
-	Date        time.Time `json:"date" validate:"required"`
+	Name        string              `json:"name" validate:"required"`
+	Description string              `json:"description" validate:"required"`
+	Location    string              `json:"location" validate:"required"`
+	Price       domain.Money        `json:"price" validate:"required_without=Tiers"`
+	TotalStock  int                 `json:"total_stock" validate:"required_without=Tiers"`
+	Image       string              `json:"image" validate:"required"`
+	Date        time.Time           `json:"date" validate:"required"`
+	Tiers       []TicketTierRequest `json:"tiers" validate:"omitempty,dive"`
+	// MaxTicketsPerUser caps how many tickets one account can hold across all
+	// of its orders, 0 uses the service default
+	MaxTicketsPerUser int `json:"max_tickets_per_user" validate:"min=0"`
+}
+
+type TicketTierRequest struct {
+	Name        string       `json:"name" validate:"required,max=50"`
+	Price       domain.Money `json:"price" validate:"required"`
+	TotalStock  int          `json:"total_stock" validate:"required"`
+	MaxPerOrder int          `json:"max_per_order" validate:"min=0"`
+	SaleStartAt *time.Time   `json:"sale_start_at"`
+	SaleEndAt   *time.Time   `json:"sale_end_at"`
+}
+
+// UpdateEventRequest is a partial update, omitted fields are left as they are.
+// Price and total_stock can only be set directly on single tier events.
+type UpdateEventRequest struct {
+	Name              *string                   `json:"name" validate:"omitempty,min=1"`
+	Description       *string                   `json:"description" validate:"omitempty,min=1"`
+	Location          *string                   `json:"location" validate:"omitempty,min=1"`
+	Price             *domain.Money             `json:"price" validate:"omitempty,gt=0"`
+	TotalStock        *int                      `json:"total_stock" validate:"omitempty,min=1"`
+	Image             *string                   `json:"image" validate:"omitempty,min=1"`
+	Date              *time.Time                `json:"date"`
+	Tiers             []UpdateTicketTierRequest `json:"tiers" validate:"omitempty,dive"`
+	MaxTicketsPerUser *int                      `json:"max_tickets_per_user" validate:"omitempty,min=0"`
+}
+
+type UpdateTicketTierRequest struct {
+	ID          uuid.UUID     `json:"id" validate:"required"`
+	Name        *string       `json:"name" validate:"omitempty,min=1,max=50"`
+	Price       *domain.Money `json:"price" validate:"omitempty,gt=0"`
+	TotalStock  *int          `json:"total_stock" validate:"omitempty,min=1"`
+	MaxPerOrder *int          `json:"max_per_order" validate:"omitempty,min=0"`
+	SaleStartAt *time.Time    `json:"sale_start_at"`
+	SaleEndAt   *time.Time    `json:"sale_end_at"`
 }
 
 type EventResponse struct {
-	ID             uuid.UUID `json:"id"`
-	Name           string    `json:"name"`
-	Description    string    `json:"description"`
-	Location       string    `json:"location"`
-	Price          float64   `json:"price"`
-	TotalStock     int       `json:"total_stock"`
-	AvailableStock int       `json:"available_stock"`
-	Image          string    `json:"image"`
-	Date           time.Time `json:"date"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                uuid.UUID            `json:"id"`
+	OrganiserID       uuid.UUID            `json:"organiser_id"`
+	Name              string               `json:"name"`
+	Description       string               `json:"description"`
+	Location          string               `json:"location"`
+	Price             domain.Money         `json:"price"`
+	TotalStock        int                  `json:"total_stock"`
+	AvailableStock    int                  `json:"available_stock"`
+	Image             string               `json:"image"`
+	Date              time.Time            `json:"date"`
+	MaxTicketsPerUser int                  `json:"max_tickets_per_user"`
+	Tiers             []TicketTierResponse `json:"tiers"`
+	CreatedAt         time.Time            `json:"created_at"`
+	UpdatedAt         time.Time            `json:"updated_at"`
+}
+
+type TicketTierResponse struct {
+	ID             uuid.UUID    `json:"id"`
+	Name           string       `json:"name"`
+	Price          domain.Money `json:"price"`
+	TotalStock     int          `json:"total_stock"`
+	AvailableStock int          `json:"available_stock"`
+	MaxPerOrder    int          `json:"max_per_order"`
+	SaleStartAt    *time.Time   `json:"sale_start_at,omitempty"`
+	SaleEndAt      *time.Time   `json:"sale_end_at,omitempty"`
 }
@@ -32,35 +32,36 @@ func (h *Handler) CreateEvent(c *fiber.Ctx) error {
 		return responses.ValidationError(c, errors)
 	}
 
+	tiers := make([]domain.TicketTier, len(req.Tiers))
+	for i, tier := range req.Tiers {
+		tiers[i] = domain.TicketTier{
+			Name:           tier.Name,
+			Price:          tier.Price,
+			TotalStock:     tier.TotalStock,
+			AvailableStock: tier.TotalStock,
+			MaxPerOrder:    tier.MaxPerOrder,
+			SaleStartAt:    tier.SaleStartAt,
+			SaleEndAt:      tier.SaleEndAt,
+		}
+	}
+
 	res, err := h.usecase.CreateEvent(c.Context(), domain.Event{
-		Name:           req.Name,
-		Description:    req.Description,
-		Location:       req.Location,
-		Price:          req.Price,
-		TotalStock:     req.TotalStock,
-		AvailableStock: req.TotalStock,
-		Image:          req.Image,
-		Date:           req.Date,
+		Name:              req.Name,
+		Description:       req.Description,
+		Location:          req.Location,
+		Price:             req.Price,
+		TotalStock:        req.TotalStock,
+		AvailableStock:    req.TotalStock,
+		Image:             req.Image,
+		Date:              req.Date,
+		MaxTicketsPerUser: req.MaxTicketsPerUser,
+		Tiers:             tiers,
 	})
 	if err != nil {
 		return responses.UsecaseError(c, err)
 	}
 
-	response := EventResponse{
-		ID:             res.ID,
-		Name:           res.Name,
-		Description:    res.Description,
-		Location:       res.Location,
-		Price:          res.Price,
-		TotalStock:     res.TotalStock,
-		AvailableStock: res.AvailableStock,
-		Image:          res.Image,
-		Date:           res.Date,
-		CreatedAt:      res.CreatedAt,
-		UpdatedAt:      res.UpdatedAt,
-	}
-
-	return responses.Success(c, response, "success")
+	return responses.Success(c, toEventResponse(*res), "success")
 }
 
 func (h *Handler) GetEventByID(c *fiber.Ctx) error {
@@ -75,21 +76,7 @@ func (h *Handler) GetEventByID(c *fiber.Ctx) error {
 		return responses.UsecaseError(c, err)
 	}
 
-	response := EventResponse{
-		ID:             res.ID,
-		Name:           res.Name,
-		Description:    res.Description,
-		Location:       res.Location,
-		Price:          res.Price,
-		TotalStock:     res.TotalStock,
-		AvailableStock: res.AvailableStock,
-		Image:          res.Image,
-		Date:           res.Date,
-		CreatedAt:      res.CreatedAt,
-		UpdatedAt:      res.UpdatedAt,
-	}
-
-	return responses.Success(c, response, "success")
+	return responses.Success(c, toEventResponse(*res), "success")
 }
 func (h *Handler) GetAllEvent(c *fiber.Ctx) error {
 	res, err := h.usecase.GetAllEvent(c.Context())
@@ -99,24 +86,60 @@ func (h *Handler) GetAllEvent(c *fiber.Ctx) error {
 
 	response := make([]EventResponse, len(res))
 	for i, event := range res {
-		response[i] = EventResponse{
-			ID:             event.ID,
-			Name:           event.Name,
-			Description:    event.Description,
-			Location:       event.Location,
-			Price:          event.Price,
-			TotalStock:     event.TotalStock,
-			AvailableStock: event.AvailableStock,
-			Image:          event.Image,
-			Date:           event.Date,
-			CreatedAt:      event.CreatedAt,
-			UpdatedAt:      event.UpdatedAt,
-		}
+		response[i] = toEventResponse(event)
 	}
 
 	return responses.Success(c, response, "success")
 }
 
+func (h *Handler) UpdateEvent(c *fiber.Ctx) error {
+	eventIDParams := c.Params("event_id")
+	eventID, err := uuid.Parse(eventIDParams)
+	if err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidID.Error())
+	}
+
+	var req UpdateEventRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		errors := h.validator.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	tiers := make([]TierChanges, len(req.Tiers))
+	for i, tier := range req.Tiers {
+		tiers[i] = TierChanges{
+			ID:          tier.ID,
+			Name:        tier.Name,
+			Price:       tier.Price,
+			TotalStock:  tier.TotalStock,
+			MaxPerOrder: tier.MaxPerOrder,
+			SaleStartAt: tier.SaleStartAt,
+			SaleEndAt:   tier.SaleEndAt,
+		}
+	}
+
+	res, err := h.usecase.UpdateEvent(c.Context(), eventID, EventChanges{
+		Name:              req.Name,
+		Description:       req.Description,
+		Location:          req.Location,
+		Date:              req.Date,
+		Image:             req.Image,
+		Price:             req.Price,
+		TotalStock:        req.TotalStock,
+		MaxTicketsPerUser: req.MaxTicketsPerUser,
+		Tiers:             tiers,
+	})
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, toEventResponse(*res), "success")
+}
+
 func (h *Handler) DeleteEvent(c *fiber.Ctx) error {
 	eventIDParams := c.Params("event_id")
 	eventID, err := uuid.Parse(eventIDParams)
@@ -130,3 +153,36 @@ func (h *Handler) DeleteEvent(c *fiber.Ctx) error {
 
 	return responses.Success(c, nil, "success")
 }
+
+func toEventResponse(event domain.Event) EventResponse {
+	tiers := make([]TicketTierResponse, len(event.Tiers))
+	for i, tier := range event.Tiers {
+		tiers[i] = TicketTierResponse{
+			ID:             tier.ID,
+			Name:           tier.Name,
+			Price:          tier.Price,
+			TotalStock:     tier.TotalStock,
+			AvailableStock: tier.AvailableStock,
+			MaxPerOrder:    tier.MaxPerOrder,
+			SaleStartAt:    tier.SaleStartAt,
+			SaleEndAt:      tier.SaleEndAt,
+		}
+	}
+
+	return EventResponse{
+		ID:                event.ID,
+		OrganiserID:       event.OrganiserID,
+		Name:              event.Name,
+		Description:       event.Description,
+		Location:          event.Location,
+		Price:             event.Price,
+		TotalStock:        event.TotalStock,
+		AvailableStock:    event.AvailableStock,
+		Image:             event.Image,
+		Date:              event.Date,
+		MaxTicketsPerUser: event.MaxTicketsPerUser,
+		Tiers:             tiers,
+		CreatedAt:         event.CreatedAt,
+		UpdatedAt:         event.UpdatedAt,
+	}
+}
@@ -3,14 +3,46 @@ package event
 import (
 	"context"
 	"go-war-ticket-service/internal/domain"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// EventChanges is a partial update, nil fields are left untouched. Price and
+// TotalStock are shorthands for events that sell a single tier.
+type EventChanges struct {
+	Name              *string
+	Description       *string
+	Location          *string
+	Date              *time.Time
+	Image             *string
+	Price             *domain.Money
+	TotalStock        *int
+	MaxTicketsPerUser *int
+	Tiers             []TierChanges
+}
+
+type TierChanges struct {
+	ID          uuid.UUID
+	Name        *string
+	Price       *domain.Money
+	TotalStock  *int
+	MaxPerOrder *int
+	SaleStartAt *time.Time
+	SaleEndAt   *time.Time
+}
+
+// StockDelta is how much a tier's available stock moved during an update
+type StockDelta struct {
+	TierID uuid.UUID
+	Delta  int
+}
+
 type Usecase interface {
 	CreateEvent(ctx context.Context, event domain.Event) (*domain.Event, error)
 	GetEventByID(ctx context.Context, eventID uuid.UUID) (*domain.Event, error)
 	GetAllEvent(ctx context.Context) ([]domain.Event, error)
+	UpdateEvent(ctx context.Context, eventID uuid.UUID, changes EventChanges) (*domain.Event, error)
 	DeleteEvent(ctx context.Context, eventID uuid.UUID) error
 }
 
@@ -18,7 +50,8 @@ type Repository interface {
 	CreateEvent(ctx context.Context, event domain.Event) (*domain.Event, error)
 	GetEventByID(ctx context.Context, eventID uuid.UUID) (*domain.Event, error)
 	GetEventByName(ctx context.Context, eventName string) (*domain.Event, error)
+	GetEventOrganiserID(ctx context.Context, eventID uuid.UUID) (uuid.UUID, error)
 	GetAllEvent(ctx context.Context) ([]domain.Event, error)
-	UpdateEvent(ctx context.Context, event domain.Event) (*domain.Event, error)
+	UpdateEvent(ctx context.Context, eventID uuid.UUID, changes EventChanges, syncCache func([]StockDelta) error) (*domain.Event, error)
 	DeleteEvent(ctx context.Context, eventID uuid.UUID) error
 }
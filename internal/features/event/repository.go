@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repository struct {
@@ -27,7 +28,7 @@ func (r *repository) CreateEvent(ctx context.Context, event domain.Event) (*doma
 
 func (r *repository) GetEventByID(ctx context.Context, eventID uuid.UUID) (*domain.Event, error) {
 	var event domain.Event
-	err := r.db.WithContext(ctx).Where("id = ?", eventID).First(&event).Error
+	err := r.db.WithContext(ctx).Preload("Tiers", tiersByPrice).Where("id = ?", eventID).First(&event).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -49,9 +50,24 @@ func (r *repository) GetEventByName(ctx context.Context, eventName string) (*dom
 	return &event, nil
 }
 
+// GetEventOrganiserID returns domain.ErrEventNotFound so it can back the
+// event ownership middleware directly
+func (r *repository) GetEventOrganiserID(ctx context.Context, eventID uuid.UUID) (uuid.UUID, error) {
+	var event domain.Event
+	err := r.db.WithContext(ctx).Select("id", "organiser_id").Where("id = ?", eventID).First(&event).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return uuid.Nil, domain.ErrEventNotFound
+		}
+		return uuid.Nil, err
+	}
+	return event.OrganiserID, nil
+}
+
 func (r *repository) GetAllEvent(ctx context.Context) ([]domain.Event, error) {
 	var events []domain.Event
 	err := r.db.WithContext(ctx).
+		Preload("Tiers", tiersByPrice).
 		Order("created_at DESC").
 		Find(&events).
 		Error
@@ -61,16 +77,154 @@ func (r *repository) GetAllEvent(ctx context.Context) ([]domain.Event, error) {
 	return events, nil
 }
 
-func (r *repository) UpdateEvent(ctx context.Context, event domain.Event) (*domain.Event, error) {
-	if err := r.db.WithContext(ctx).Save(&event).Error; err != nil {
+// UpdateEvent applies changes in one transaction. Tier rows are locked while
+// their stock moves, so the sold count checked against the new total can't
+// change underneath us. syncCache runs last, inside the transaction, so a
+// cache failure rolls the update back.
+func (r *repository) UpdateEvent(ctx context.Context, eventID uuid.UUID, changes EventChanges, syncCache func([]StockDelta) error) (*domain.Event, error) {
+	var event domain.Event
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", eventID).
+			First(&event).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrEventNotFound
+			}
+			return err
+		}
+
+		fields := map[string]interface{}{}
+		if changes.Name != nil {
+			fields["name"] = *changes.Name
+		}
+		if changes.Description != nil {
+			fields["description"] = *changes.Description
+		}
+		if changes.Location != nil {
+			fields["location"] = *changes.Location
+		}
+		if changes.Date != nil {
+			fields["date"] = *changes.Date
+		}
+		if changes.Image != nil {
+			fields["image"] = *changes.Image
+		}
+		if changes.MaxTicketsPerUser != nil {
+			fields["max_tickets_per_user"] = *changes.MaxTicketsPerUser
+		}
+
+		if len(fields) > 0 {
+			if err := tx.Model(&event).Updates(fields).Error; err != nil {
+				return err
+			}
+		}
+
+		var deltas []StockDelta
+		for _, change := range changes.Tiers {
+			delta, err := updateTier(tx, eventID, change)
+			if err != nil {
+				return err
+			}
+
+			if delta != 0 {
+				deltas = append(deltas, StockDelta{TierID: change.ID, Delta: delta})
+			}
+		}
+
+		// Event level price and stock summarise the tiers
+		if len(changes.Tiers) > 0 {
+			tiers := tx.Model(&domain.TicketTier{}).Where("event_id = ?", eventID)
+
+			if err := tx.Model(&domain.Event{}).
+				Where("id = ?", eventID).
+				UpdateColumns(map[string]interface{}{
+					"price":           tiers.Session(&gorm.Session{}).Select("MIN(price)"),
+					"total_stock":     tiers.Session(&gorm.Session{}).Select("SUM(total_stock)"),
+					"available_stock": tiers.Session(&gorm.Session{}).Select("SUM(available_stock)"),
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Preload("Tiers", tiersByPrice).First(&event, "id = ?", eventID).Error; err != nil {
+			return err
+		}
+
+		if len(deltas) > 0 {
+			return syncCache(deltas)
+		}
+
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
+
 	return &event, nil
 }
 
+// updateTier applies one tier's changes and returns how much its available
+// stock moved. Capacity changes are relative, so seats sold so far stay sold.
+func updateTier(tx *gorm.DB, eventID uuid.UUID, change TierChanges) (int, error) {
+	var tier domain.TicketTier
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND event_id = ?", change.ID, eventID).
+		First(&tier).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, domain.ErrTierNotFound
+		}
+		return 0, err
+	}
+
+	fields := map[string]interface{}{}
+	if change.Name != nil {
+		fields["name"] = *change.Name
+	}
+	if change.Price != nil {
+		fields["price"] = *change.Price
+	}
+	if change.MaxPerOrder != nil {
+		fields["max_per_order"] = *change.MaxPerOrder
+	}
+	if change.SaleStartAt != nil {
+		fields["sale_start_at"] = *change.SaleStartAt
+	}
+	if change.SaleEndAt != nil {
+		fields["sale_end_at"] = *change.SaleEndAt
+	}
+
+	delta := 0
+	if change.TotalStock != nil {
+		sold := tier.TotalStock - tier.AvailableStock
+		if *change.TotalStock < sold {
+			return 0, domain.ErrStockBelowSold
+		}
+
+		delta = *change.TotalStock - tier.TotalStock
+		fields["total_stock"] = *change.TotalStock
+		fields["available_stock"] = gorm.Expr("available_stock + ?", delta)
+	}
+
+	if len(fields) == 0 {
+		return 0, nil
+	}
+
+	if err := tx.Model(&tier).Updates(fields).Error; err != nil {
+		return 0, err
+	}
+
+	return delta, nil
+}
+
 func (r *repository) DeleteEvent(ctx context.Context, eventID uuid.UUID) error {
 	if err := r.db.WithContext(ctx).Delete(&domain.Event{}, "id = ?", eventID).Error; err != nil {
 		return err
 	}
 	return nil
 }
+
+// tiersByPrice lists an event's tiers from cheapest to most expensive
+func tiersByPrice(db *gorm.DB) *gorm.DB {
+	return db.Order("price ASC")
+}
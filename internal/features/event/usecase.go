@@ -2,24 +2,31 @@ package event
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/cache"
 	"go-war-ticket-service/internal/platform/storage"
 	"go-war-ticket-service/internal/utils"
+	"go-war-ticket-service/internal/utils/contextutil"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
+const defaultTierName = "Regular"
+
 type usecase struct {
 	repo        Repository
 	log         *zap.SugaredLogger
 	minioClient *minio.Client
 	cfg         configs.Config
+	cache       *redis.Client
 }
 
 func NewUsecase(
@@ -27,23 +34,46 @@ func NewUsecase(
 	log *zap.SugaredLogger,
 	minioClient *minio.Client,
 	cfg configs.Config,
+	cache *redis.Client,
 ) Usecase {
 	return &usecase{
 		repo:        r,
 		log:         log.Named("EventUsecase"),
 		minioClient: minioClient,
 		cfg:         cfg,
+		cache:       cache,
 	}
 }
 
 func (u *usecase) CreateEvent(ctx context.Context, event domain.Event) (*domain.Event, error) {
-	if event.TotalStock <= 0 {
-		return nil, domain.ErrInvalidStock
+	organiserID, err := contextutil.GetUserID(ctx)
+	if err != nil {
+		return nil, domain.ErrUnauthorized
+	}
+	event.OrganiserID = organiserID
+
+	// Events without explicit tiers sell a single default tier
+	if len(event.Tiers) == 0 {
+		event.Tiers = []domain.TicketTier{{
+			Name:           defaultTierName,
+			Price:          event.Price,
+			TotalStock:     event.TotalStock,
+			AvailableStock: event.TotalStock,
+		}}
+	}
+
+	if err := validateTiers(event.Tiers); err != nil {
+		return nil, err
 	}
 
-	if event.Price <= 0 {
-		return nil, domain.ErrInvalidPrice
+	// Event level price and stock summarise the tiers
+	event.Price = event.Tiers[0].Price
+	event.TotalStock = 0
+	for _, tier := range event.Tiers {
+		event.Price = min(event.Price, tier.Price)
+		event.TotalStock += tier.TotalStock
 	}
+	event.AvailableStock = event.TotalStock
 
 	if event.Date.IsZero() {
 		return nil, domain.ErrInvalidDate
@@ -123,6 +153,112 @@ func (u *usecase) GetAllEvent(ctx context.Context) ([]domain.Event, error) {
 	return events, nil
 }
 
+func (u *usecase) UpdateEvent(ctx context.Context, eventID uuid.UUID, changes EventChanges) (*domain.Event, error) {
+	event, err := u.repo.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event == nil {
+		return nil, domain.ErrEventNotFound
+	}
+
+	// Event level price and stock only make sense when there is a single tier
+	if changes.Price != nil || changes.TotalStock != nil {
+		if len(event.Tiers) != 1 {
+			return nil, domain.ErrTierRequired
+		}
+
+		changes.Tiers = append(changes.Tiers, TierChanges{
+			ID:         event.Tiers[0].ID,
+			Price:      changes.Price,
+			TotalStock: changes.TotalStock,
+		})
+		changes.Price = nil
+		changes.TotalStock = nil
+	}
+
+	if err := validateTierChanges(event.Tiers, changes.Tiers); err != nil {
+		return nil, err
+	}
+
+	if changes.Date != nil && changes.Date.IsZero() {
+		return nil, domain.ErrInvalidDate
+	}
+
+	oldImage := event.Image
+	if changes.Image != nil {
+		name := event.Name
+		if changes.Name != nil {
+			name = *changes.Name
+		}
+
+		filename := fmt.Sprintf("%s-%s", strings.ReplaceAll(name, " ", "-"), utils.GenerateRandomNumberString(6))
+		path, err := storage.UploadImageToMinIO(
+			u.minioClient,
+			u.cfg.MinioBucket,
+			*changes.Image,
+			"events",
+			filename,
+		)
+		if err != nil {
+			u.log.Error("failed to upload image to minio: ", err)
+			return nil, domain.ErrInternal
+		}
+
+		changes.Image = &path
+	}
+
+	updated, err := u.repo.UpdateEvent(ctx, eventID, changes, func(deltas []StockDelta) error {
+		return u.syncStockInRedis(ctx, eventID, deltas)
+	})
+	if err != nil {
+		if changes.Image != nil {
+			_ = storage.DeleteObjectFromMinIO(u.minioClient, u.cfg.MinioBucket, *changes.Image)
+		}
+		if errors.Is(err, domain.ErrEventNotFound) || errors.Is(err, domain.ErrTierNotFound) || errors.Is(err, domain.ErrStockBelowSold) {
+			return nil, err
+		}
+		u.log.Errorf("failed to update event %s: %v", eventID, err)
+		return nil, domain.ErrInternal
+	}
+
+	if changes.Image != nil {
+		if err := storage.DeleteObjectFromMinIO(u.minioClient, u.cfg.MinioBucket, oldImage); err != nil {
+			u.log.Error("failed to delete old image from minio: ", err)
+		}
+	}
+
+	presignedURL, err := storage.GetPresignedObject(
+		u.minioClient,
+		u.cfg.MinioBucket,
+		updated.Image,
+		u.cfg.MinioEndpoint,
+		u.cfg.MinioPublicEndpoint,
+		time.Minute*15,
+	)
+	if err != nil {
+		u.log.Error("failed to generate presigned url for image: ", err)
+		return nil, domain.ErrInternal
+	}
+
+	updated.Image = presignedURL
+
+	return updated, nil
+}
+
+// syncStockInRedis shifts the cached tier stock by the same amount the DB
+// moved, so seats reserved in Redis but not yet written stay accounted for
+func (u *usecase) syncStockInRedis(ctx context.Context, eventID uuid.UUID, deltas []StockDelta) error {
+	for _, d := range deltas {
+		redisKey := fmt.Sprintf(utils.EventTierStockKey, eventID.String(), d.TierID.String())
+		if err := cache.IncrByIfExists(ctx, u.cache, redisKey, d.Delta); err != nil {
+			return fmt.Errorf("failed to sync redis stock: %w", err)
+		}
+	}
+	return nil
+}
+
 func (u *usecase) DeleteEvent(ctx context.Context, eventID uuid.UUID) error {
 	event, err := u.repo.GetEventByID(ctx, eventID)
 	if err != nil {
@@ -145,3 +281,64 @@ func (u *usecase) DeleteEvent(ctx context.Context, eventID uuid.UUID) error {
 
 	return u.repo.DeleteEvent(ctx, eventID)
 }
+
+func validateTiers(tiers []domain.TicketTier) error {
+	for _, tier := range tiers {
+		if tier.TotalStock <= 0 || tier.MaxPerOrder < 0 {
+			return domain.ErrInvalidStock
+		}
+
+		if tier.Price <= 0 {
+			return domain.ErrInvalidPrice
+		}
+
+		if tier.SaleStartAt != nil && tier.SaleEndAt != nil && !tier.SaleEndAt.After(*tier.SaleStartAt) {
+			return domain.ErrInvalidDate
+		}
+	}
+
+	return nil
+}
+
+// validateTierChanges checks each change merged over the tier it targets.
+// Stock against sold seats is checked again under lock in the repository.
+func validateTierChanges(current []domain.TicketTier, changes []TierChanges) error {
+	byID := make(map[uuid.UUID]domain.TicketTier, len(current))
+	for _, tier := range current {
+		byID[tier.ID] = tier
+	}
+
+	merged := make([]domain.TicketTier, 0, len(changes))
+	for _, change := range changes {
+		tier, ok := byID[change.ID]
+		if !ok {
+			return domain.ErrTierNotFound
+		}
+
+		if change.Name != nil {
+			tier.Name = *change.Name
+		}
+		if change.Price != nil {
+			tier.Price = *change.Price
+		}
+		if change.TotalStock != nil {
+			if *change.TotalStock < tier.TotalStock-tier.AvailableStock {
+				return domain.ErrStockBelowSold
+			}
+			tier.TotalStock = *change.TotalStock
+		}
+		if change.MaxPerOrder != nil {
+			tier.MaxPerOrder = *change.MaxPerOrder
+		}
+		if change.SaleStartAt != nil {
+			tier.SaleStartAt = change.SaleStartAt
+		}
+		if change.SaleEndAt != nil {
+			tier.SaleEndAt = change.SaleEndAt
+		}
+
+		merged = append(merged, tier)
+	}
+
+	return validateTiers(merged)
+}
@@ -0,0 +1,14 @@
+package notification
+
+import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+type Repository interface {
+	GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error)
+	GetDelivery(ctx context.Context, orderID uuid.UUID, kind domain.EmailKind) (*domain.EmailDelivery, error)
+	SaveDelivery(ctx context.Context, delivery *domain.EmailDelivery) error
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error) {
+	var order domain.Order
+	err := r.db.WithContext(ctx).
+		Where("booking_id = ?", bookingID).
+		Preload("User").
+		Preload("Event").
+		Preload("Tier").
+		Preload("Ticket", "status = ?", domain.TicketStatusValid).
+		First(&order).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *repository) GetDelivery(ctx context.Context, orderID uuid.UUID, kind domain.EmailKind) (*domain.EmailDelivery, error) {
+	var delivery domain.EmailDelivery
+	err := r.db.WithContext(ctx).Where("order_id = ? AND kind = ?", orderID, kind).First(&delivery).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *repository) SaveDelivery(ctx context.Context, delivery *domain.EmailDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
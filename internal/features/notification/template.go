@@ -0,0 +1,95 @@
+package notification
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+)
+
+type ticketLine struct {
+	Number string
+	Link   string // presigned download link, empty when the PDF is attached
+}
+
+type ticketsReadyData struct {
+	FullName  string
+	BookingID string
+	EventName string
+	Location  string
+	EventDate time.Time
+	TierName  string
+	Tickets   []ticketLine
+	Attached  bool
+}
+
+var templateFuncs = map[string]interface{}{
+	"date": func(t time.Time) string { return t.Format("Monday, 02 January 2006 15:04 MST") },
+}
+
+const ticketsReadyText = `Hi {{.FullName}},
+
+Your tickets for {{.EventName}} are ready.
+
+Booking ID: {{.BookingID}}
+Tier:       {{.TierName}}
+When:       {{date .EventDate}}
+Where:      {{.Location}}
+
+{{if .Attached}}Your tickets are attached to this email as PDFs.{{else}}Download your tickets:
+{{range .Tickets}}
+- {{.Number}}: {{.Link}}{{end}}
+
+The download links expire in 7 days, you can always get fresh ones from your order page.{{end}}
+
+Show the QR code on each ticket at the gate. Each ticket can be scanned once.
+
+See you there!
+TiBo
+`
+
+const ticketsReadyHTML = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #222;">
+	<p>Hi {{.FullName}},</p>
+	<p>Your tickets for <strong>{{.EventName}}</strong> are ready.</p>
+	<table cellpadding="4">
+		<tr><td>Booking ID</td><td><strong>{{.BookingID}}</strong></td></tr>
+		<tr><td>Tier</td><td>{{.TierName}}</td></tr>
+		<tr><td>When</td><td>{{date .EventDate}}</td></tr>
+		<tr><td>Where</td><td>{{.Location}}</td></tr>
+	</table>
+	{{if .Attached}}
+	<p>Your tickets are attached to this email as PDFs.</p>
+	{{else}}
+	<p>Download your tickets:</p>
+	<ul>
+		{{range .Tickets}}<li><a href="{{.Link}}">{{.Number}}</a></li>{{end}}
+	</ul>
+	<p>The download links expire in 7 days, you can always get fresh ones from your order page.</p>
+	{{end}}
+	<p>Show the QR code on each ticket at the gate. Each ticket can be scanned once.</p>
+	<p>See you there!<br>TiBo</p>
+</body>
+</html>
+`
+
+var (
+	ticketsReadyTextTmpl = texttemplate.Must(texttemplate.New("tickets_ready_text").Funcs(templateFuncs).Parse(ticketsReadyText))
+	ticketsReadyHTMLTmpl = htmltemplate.Must(htmltemplate.New("tickets_ready_html").Funcs(templateFuncs).Parse(ticketsReadyHTML))
+)
+
+// renderTicketsReady returns the plain text and HTML bodies of the email
+func renderTicketsReady(data ticketsReadyData) (string, string, error) {
+	var text, html bytes.Buffer
+
+	if err := ticketsReadyTextTmpl.Execute(&text, data); err != nil {
+		return "", "", err
+	}
+
+	if err := ticketsReadyHTMLTmpl.Execute(&html, data); err != nil {
+		return "", "", err
+	}
+
+	return text.String(), html.String(), nil
+}
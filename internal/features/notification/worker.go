@@ -0,0 +1,281 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/mailer"
+	rabbitmq "go-war-ticket-service/internal/platform/message_broker/rabbit_mq"
+	"go-war-ticket-service/internal/platform/storage"
+	"go-war-ticket-service/internal/utils"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAttachMaxSize = 10 << 20
+	ticketLinkExpiry     = 7 * 24 * time.Hour // longest presign MinIO allows
+	sendTimeout          = 30 * time.Second
+)
+
+// NotificationWorker emails customers their tickets once they are generated
+type NotificationWorker struct {
+	mq          rabbitmq.Publisher
+	repo        Repository
+	mailer      mailer.Mailer
+	minioClient *minio.Client
+	cfg         configs.Config
+	log         *zap.SugaredLogger
+}
+
+func NewNotificationWorker(
+	mq rabbitmq.Publisher,
+	r Repository,
+	m mailer.Mailer,
+	mc *minio.Client,
+	cfg configs.Config,
+	logger *zap.SugaredLogger,
+) *NotificationWorker {
+	return &NotificationWorker{
+		mq:          mq,
+		repo:        r,
+		mailer:      m,
+		minioClient: mc,
+		cfg:         cfg,
+		log:         logger.Named("NotificationWorker"),
+	}
+}
+
+// Start emails tickets until ctx is cancelled, finishing the emails that
+// are being sent when that happens
+func (w *NotificationWorker) Start(ctx context.Context) {
+	consumer := rabbitmq.NewConsumer(w.cfg.RabbitMQURL, w.mq, rabbitmq.ConsumerConfig{
+		Queue:       utils.QueueTicketsReady,
+		Concurrency: w.cfg.NotificationWorkerConcurrency,
+		Prefetch:    w.cfg.NotificationWorkerPrefetch,
+		Retry: rabbitmq.RetryPolicy{
+			MaxAttempts: w.cfg.QueueMaxAttempts,
+			BaseDelay:   w.cfg.QueueRetryDelay,
+			MaxDelay:    w.cfg.QueueRetryMaxDelay,
+		},
+		OnDeadLetter: w.markFailed,
+	}, w.processMessage, w.log)
+
+	consumer.Run(ctx)
+}
+
+// processMessage emails one order's tickets. Every attempt is recorded on
+// the delivery, errors returned go to the consumer's retry policy.
+func (w *NotificationWorker) processMessage(ctx context.Context, d amqp.Delivery) error {
+	var payload struct {
+		BookingID string `json:"booking_id"`
+	}
+
+	if err := json.Unmarshal(d.Body, &payload); err != nil {
+		return fmt.Errorf("%w: malformed body: %v", rabbitmq.ErrPermanent, err)
+	}
+
+	order, err := w.repo.GetOrderByBookingID(ctx, payload.BookingID)
+	if err != nil {
+		return err
+	}
+
+	if order == nil {
+		return fmt.Errorf("%w: order %s not found", rabbitmq.ErrPermanent, payload.BookingID)
+	}
+
+	delivery, err := w.repo.GetDelivery(ctx, order.ID, domain.EmailKindTicketsReady)
+	if err != nil {
+		return err
+	}
+
+	if delivery == nil {
+		delivery = &domain.EmailDelivery{
+			OrderID:   order.ID,
+			Kind:      domain.EmailKindTicketsReady,
+			UserID:    order.UserID,
+			Recipient: order.User.Email,
+			Status:    domain.EmailDeliveryPending,
+		}
+	}
+
+	// Redelivered message for an email that already went out
+	if delivery.Status == domain.EmailDeliverySent {
+		return nil
+	}
+
+	msg, err := w.buildTicketsReady(ctx, order)
+	if err != nil {
+		return fmt.Errorf("%w: %v", rabbitmq.ErrPermanent, err)
+	}
+
+	return w.deliver(ctx, delivery, msg)
+}
+
+// deliver makes a single attempt to send msg and records it, the consumer
+// redelivers the message with backoff when it fails
+func (w *NotificationWorker) deliver(ctx context.Context, delivery *domain.EmailDelivery, msg mailer.Message) error {
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	err := w.mailer.Send(sendCtx, msg)
+	cancel()
+
+	delivery.Attempts++
+
+	if err != nil {
+		w.log.Warnf("Attempt %d to email order %s failed: %v", delivery.Attempts, delivery.OrderID, err)
+		delivery.Status = domain.EmailDeliveryPending
+		delivery.LastError = err.Error()
+		w.save(ctx, delivery)
+		return fmt.Errorf("failed to email order %s: %w", delivery.OrderID, err)
+	}
+
+	now := time.Now()
+	delivery.Status = domain.EmailDeliverySent
+	delivery.SentAt = &now
+	delivery.LastError = ""
+	w.save(ctx, delivery)
+
+	w.log.Infof("Tickets for order %s emailed to %s", delivery.OrderID, delivery.Recipient)
+	return nil
+}
+
+// markFailed records that the email gave up, right before its message is
+// parked in the dead-letter queue
+func (w *NotificationWorker) markFailed(ctx context.Context, d amqp.Delivery, cause error) {
+	var payload struct {
+		BookingID string `json:"booking_id"`
+	}
+	if err := json.Unmarshal(d.Body, &payload); err != nil || payload.BookingID == "" {
+		return
+	}
+
+	order, err := w.repo.GetOrderByBookingID(ctx, payload.BookingID)
+	if err != nil || order == nil {
+		w.log.Errorf("failed to mark the email for Booking ID %s as failed: order not loaded: %v", payload.BookingID, err)
+		return
+	}
+
+	delivery, err := w.repo.GetDelivery(ctx, order.ID, domain.EmailKindTicketsReady)
+	if err != nil {
+		w.log.Errorf("failed to mark the email for Booking ID %s as failed: %v", payload.BookingID, err)
+		return
+	}
+	if delivery == nil {
+		delivery = &domain.EmailDelivery{
+			OrderID:   order.ID,
+			Kind:      domain.EmailKindTicketsReady,
+			UserID:    order.UserID,
+			Recipient: order.User.Email,
+		}
+	}
+
+	delivery.Status = domain.EmailDeliveryFailed
+	delivery.LastError = cause.Error()
+	w.save(ctx, delivery)
+
+	w.log.Errorf("Giving up emailing order %s after %d attempts: %v", order.BookingID, delivery.Attempts, cause)
+}
+
+func (w *NotificationWorker) save(ctx context.Context, delivery *domain.EmailDelivery) {
+	if err := w.repo.SaveDelivery(ctx, delivery); err != nil {
+		w.log.Errorf("failed to save email delivery for order %s: %v", delivery.OrderID, err)
+	}
+}
+
+// buildTicketsReady attaches the ticket PDFs when they fit under the size
+// limit and falls back to presigned download links otherwise
+func (w *NotificationWorker) buildTicketsReady(ctx context.Context, order *domain.Order) (mailer.Message, error) {
+	attachments, attached := w.loadAttachments(ctx, order.Ticket)
+
+	data := ticketsReadyData{
+		FullName:  order.User.FullName,
+		BookingID: order.BookingID,
+		EventName: order.Event.Name,
+		Location:  order.Event.Location,
+		EventDate: order.Event.Date,
+		TierName:  order.Tier.Name,
+		Attached:  attached,
+	}
+
+	for _, ticket := range order.Ticket {
+		line := ticketLine{Number: ticket.TicketNumber}
+
+		if !attached {
+			link, err := storage.GetPresignedObject(
+				w.minioClient,
+				w.cfg.MinioBucket,
+				ticket.PDFUrl,
+				w.cfg.MinioEndpoint,
+				w.cfg.MinioPublicEndpoint,
+				ticketLinkExpiry,
+			)
+			if err != nil {
+				return mailer.Message{}, fmt.Errorf("failed to presign ticket %s: %w", ticket.TicketNumber, err)
+			}
+			line.Link = link
+		}
+
+		data.Tickets = append(data.Tickets, line)
+	}
+
+	text, html, err := renderTicketsReady(data)
+	if err != nil {
+		return mailer.Message{}, fmt.Errorf("failed to render email: %w", err)
+	}
+
+	msg := mailer.Message{
+		To:      []string{order.User.Email},
+		Subject: fmt.Sprintf("Your tickets for %s", order.Event.Name),
+		Text:    text,
+		HTML:    html,
+	}
+	if attached {
+		msg.Attachments = attachments
+	}
+
+	return msg, nil
+}
+
+func (w *NotificationWorker) loadAttachments(ctx context.Context, tickets []domain.Ticket) ([]mailer.Attachment, bool) {
+	maxSize := w.cfg.NotificationAttachMaxSize
+	if maxSize <= 0 {
+		maxSize = defaultAttachMaxSize
+	}
+
+	var total int64
+	attachments := make([]mailer.Attachment, 0, len(tickets))
+
+	for _, ticket := range tickets {
+		object, err := w.minioClient.GetObject(ctx, w.cfg.MinioBucket, ticket.PDFUrl, minio.GetObjectOptions{})
+		if err != nil {
+			w.log.Warnf("failed to get ticket PDF %s, sending links instead: %v", ticket.PDFUrl, err)
+			return nil, false
+		}
+
+		data, err := io.ReadAll(io.LimitReader(object, maxSize-total+1))
+		object.Close()
+		if err != nil {
+			w.log.Warnf("failed to read ticket PDF %s, sending links instead: %v", ticket.PDFUrl, err)
+			return nil, false
+		}
+
+		total += int64(len(data))
+		if total > maxSize {
+			return nil, false
+		}
+
+		attachments = append(attachments, mailer.Attachment{
+			Filename:    ticket.TicketNumber + ".pdf",
+			ContentType: "application/pdf",
+			Data:        data,
+		})
+	}
+
+	return attachments, true
+}
@@ -0,0 +1,26 @@
+package order
+
+import (
+	"errors"
+	"go-war-ticket-service/internal/platform/idgen"
+)
+
+const (
+	bookingIDPrefix      = "WT-"
+	bookingCodeLength    = 10 // random characters, before the check character
+	maxBookingIDAttempts = 3
+)
+
+// errBookingIDTaken means a freshly generated booking ID is already used by
+// a reservation or an order, and a new one has to be drawn
+var errBookingIDTaken = errors.New("booking id already taken")
+
+// newBookingID returns a booking ID such as WT-7K3M9Q2XJHD. The last
+// character is a check character, so a mistyped ID never matches an order.
+func newBookingID(ids *idgen.Generator) (string, error) {
+	code, err := ids.Generate(bookingCodeLength)
+	if err != nil {
+		return "", err
+	}
+	return bookingIDPrefix + code, nil
+}
@@ -0,0 +1,24 @@
+package order
+
+import (
+	"go-war-ticket-service/internal/platform/idgen"
+	"strings"
+	"testing"
+)
+
+func TestNewBookingIDCarriesCheckCharacter(t *testing.T) {
+	ids := idgen.NewCrockford()
+
+	id, err := newBookingID(ids)
+	if err != nil {
+		t.Fatalf("new booking id: %v", err)
+	}
+
+	code, ok := strings.CutPrefix(id, bookingIDPrefix)
+	if !ok || len(code) != bookingCodeLength+1 {
+		t.Fatalf("expected %s followed by %d characters, got %q", bookingIDPrefix, bookingCodeLength+1, id)
+	}
+	if !ids.Valid(code) {
+		t.Fatalf("booking id %q has a wrong check character", id)
+	}
+}
@@ -1,6 +1,7 @@
 package order
 
 import (
+	"go-war-ticket-service/internal/domain"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,17 +9,27 @@ import (
 
 type OrderRequest struct {
 	EventID  uuid.UUID `json:"event_id" validate:"required"`
+	TierID   uuid.UUID `json:"tier_id" validate:"required"`
 	Quantity int       `json:"quantity" validate:"required,numeric,min=1"`
 }
 
 type OrderResponse struct {
-	BookingID string    `json:"booking_id"`
-	Event     Event     `json:"event"`
-	Quantity  int       `json:"quantity"`
-	Total     float64   `json:"total"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	Tickets   []string  `json:"tickets,omitempty"`
+	BookingID  string       `json:"booking_id"`
+	Event      Event        `json:"event"`
+	Tier       string       `json:"tier"`
+	Quantity   int          `json:"quantity"`
+	UnitPrice  domain.Money `json:"unit_price"`
+	Subtotal   domain.Money `json:"subtotal"`
+	Fee        domain.Money `json:"fee"`
+	Discount   domain.Money `json:"discount"`
+	Total      domain.Money `json:"total"`
+	Currency   string       `json:"currency"`
+	Status     string       `json:"status"`
+	Failure    string       `json:"failure_reason,omitempty"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	PaymentURL string       `json:"payment_url,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	Tickets    []string     `json:"tickets,omitempty"`
 }
 
 type Event struct {
@@ -28,7 +39,33 @@ type Event struct {
 	Image    string    `json:"image"`
 }
 
-type PaymentWebhookRequest struct {
-	BookingID     string `json:"booking_id" validate:"required"`
-	PaymentStatus string `json:"payment_status" validate:"required"`
+type RefundRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type RequeueRequest struct {
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+type OrderStatusHistoryResponse struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Actor      string    `json:"actor"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type StockReportResponse struct {
+	EventID uuid.UUID           `json:"event_id"`
+	Drifted bool                `json:"drifted"`
+	Tiers   []TierDriftResponse `json:"tiers"`
+}
+
+type TierDriftResponse struct {
+	TierID   uuid.UUID `json:"tier_id"`
+	Expected int       `json:"expected"` // total stock minus seats held by orders
+	Database int       `json:"database"`
+	Cached   *int      `json:"cached"` // null when the tier isn't cached
+	Drifted  bool      `json:"drifted"`
+	Repaired bool      `json:"repaired"`
 }
@@ -4,8 +4,10 @@ import (
 	"go-war-ticket-service/internal/domain"
 	"go-war-ticket-service/internal/platform/responses"
 	"go-war-ticket-service/internal/platform/validator"
+	"net/http"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
@@ -35,6 +37,7 @@ func (h *Handler) CreateOrder(c *fiber.Ctx) error {
 
 	order := domain.Order{
 		EventID:  orderReq.EventID,
+		TierID:   orderReq.TierID,
 		Quantity: orderReq.Quantity,
 	}
 
@@ -51,10 +54,18 @@ func (h *Handler) CreateOrder(c *fiber.Ctx) error {
 			Date:     createdOrder.Event.Date,
 			Image:    createdOrder.Event.Image,
 		},
-		Quantity:  createdOrder.Quantity,
-		Total:     createdOrder.Event.Price * float64(createdOrder.Quantity),
-		Status:    string(createdOrder.Status),
-		CreatedAt: createdOrder.CreatedAt,
+		Quantity:   createdOrder.Quantity,
+		Tier:       createdOrder.Tier.Name,
+		UnitPrice:  createdOrder.UnitPrice,
+		Subtotal:   createdOrder.Subtotal,
+		Fee:        createdOrder.Fee,
+		Discount:   createdOrder.Discount,
+		Total:      createdOrder.TotalPrice,
+		Currency:   createdOrder.Currency,
+		Status:     string(createdOrder.Status),
+		ExpiresAt:  createdOrder.ExpiresAt,
+		PaymentURL: createdOrder.PaymentURL,
+		CreatedAt:  createdOrder.CreatedAt,
 	}
 
 	return responses.Success(c, response, "Order created successfully")
@@ -79,11 +90,19 @@ func (h *Handler) GetOrderByBookingID(c *fiber.Ctx) error {
 			Date:     order.Event.Date,
 			Image:    order.Event.Image,
 		},
-		Quantity:  order.Quantity,
-		Total:     order.Event.Price * float64(order.Quantity),
-		Status:    string(order.Status),
-		CreatedAt: order.CreatedAt,
-		Tickets:   []string{},
+		Quantity:   order.Quantity,
+		Tier:       order.Tier.Name,
+		UnitPrice:  order.UnitPrice,
+		Subtotal:   order.Subtotal,
+		Fee:        order.Fee,
+		Discount:   order.Discount,
+		Total:      order.TotalPrice,
+		Currency:   order.Currency,
+		Status:     string(order.Status),
+		ExpiresAt:  order.ExpiresAt,
+		PaymentURL: order.PaymentURL,
+		CreatedAt:  order.CreatedAt,
+		Tickets:    []string{},
 	}
 
 	for _, ticket := range order.Ticket {
@@ -110,8 +129,15 @@ func (h *Handler) GetOrderList(c *fiber.Ctx) error {
 				Image:    order.Event.Image,
 			},
 			Quantity:  order.Quantity,
-			Total:     order.Event.Price * float64(order.Quantity),
+			Tier:      order.Tier.Name,
+			UnitPrice: order.UnitPrice,
+			Subtotal:  order.Subtotal,
+			Fee:       order.Fee,
+			Discount:  order.Discount,
+			Total:     order.TotalPrice,
+			Currency:  order.Currency,
 			Status:    string(order.Status),
+			ExpiresAt: order.ExpiresAt,
 			CreatedAt: order.CreatedAt,
 		}
 	}
@@ -119,15 +145,157 @@ func (h *Handler) GetOrderList(c *fiber.Ctx) error {
 	return responses.Success(c, response, "Orders retrieved successfully")
 }
 
-func (h *Handler) ProcessPaymentWebhook(c *fiber.Ctx) error {
-	var payload PaymentWebhookRequest
-	if err := c.BodyParser(&payload); err != nil {
-		return responses.Error(c, fiber.StatusInternalServerError, err.Error())
+func (h *Handler) CancelOrder(c *fiber.Ctx) error {
+	bookingID := c.Params("booking_id")
+	if bookingID == "" {
+		return responses.Error(c, fiber.StatusBadRequest, "Invalid booking ID")
+	}
+
+	order, err := h.usecase.CancelOrder(c.Context(), bookingID)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, toOrderResponse(order), "Order cancelled successfully")
+}
+
+func (h *Handler) RefundOrder(c *fiber.Ctx) error {
+	bookingID := c.Params("booking_id")
+	if bookingID == "" {
+		return responses.Error(c, fiber.StatusBadRequest, "Invalid booking ID")
+	}
+
+	var req RefundRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		errors := h.validator.FormatErrors(err)
+		return responses.ValidationError(c, errors)
 	}
 
-	if err := h.service.ProcessPaymentWebhook(c.Context(), payload); err != nil {
+	order, err := h.service.RefundOrder(c.Context(), bookingID, req.Reason)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, toOrderResponse(order), "Order refunded successfully")
+}
+
+// RequeueOrder sends a FAILED order back to ticket generation
+func (h *Handler) RequeueOrder(c *fiber.Ctx) error {
+	bookingID := c.Params("booking_id")
+	if bookingID == "" {
+		return responses.Error(c, fiber.StatusBadRequest, "Invalid booking ID")
+	}
+
+	var req RequeueRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return responses.Error(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		errors := h.validator.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	order, err := h.service.RequeueOrder(c.Context(), bookingID, req.Reason)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, toOrderResponse(order), "Order requeued for ticket generation")
+}
+
+func (h *Handler) GetOrderStatusHistory(c *fiber.Ctx) error {
+	bookingID := c.Params("booking_id")
+	if bookingID == "" {
+		return responses.Error(c, fiber.StatusBadRequest, "Invalid booking ID")
+	}
+
+	history, err := h.usecase.GetOrderStatusHistory(c.Context(), bookingID)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	response := make([]OrderStatusHistoryResponse, len(history))
+	for i, entry := range history {
+		response[i] = OrderStatusHistoryResponse{
+			FromStatus: string(entry.FromStatus),
+			ToStatus:   string(entry.ToStatus),
+			Actor:      entry.Actor,
+			Reason:     entry.Reason,
+			CreatedAt:  entry.CreatedAt,
+		}
+	}
+
+	return responses.Success(c, response, "Order history retrieved successfully")
+}
+
+func (h *Handler) ProcessPaymentWebhook(c *fiber.Ctx) error {
+	headers := http.Header(c.GetReqHeaders())
+
+	if err := h.service.ProcessPaymentWebhook(c.Context(), c.Body(), headers); err != nil {
 		return responses.UsecaseError(c, err)
 	}
 
 	return responses.Success(c, nil, "Payment processed successfully")
 }
+
+func toOrderResponse(order *domain.Order) OrderResponse {
+	return OrderResponse{
+		BookingID: order.BookingID,
+		Event: Event{
+			Name:     order.Event.Name,
+			Location: order.Event.Location,
+			Date:     order.Event.Date,
+		},
+		Quantity:  order.Quantity,
+		Tier:      order.Tier.Name,
+		UnitPrice: order.UnitPrice,
+		Subtotal:  order.Subtotal,
+		Fee:       order.Fee,
+		Discount:  order.Discount,
+		Total:     order.TotalPrice,
+		Currency:  order.Currency,
+		Status:    string(order.Status),
+		Failure:   order.FailureReason,
+		CreatedAt: order.CreatedAt,
+	}
+}
+
+// ReconcileStock checks one event's stock for drift, ?repair=true also fixes it
+func (h *Handler) ReconcileStock(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("event_id"))
+	if err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidID.Error())
+	}
+
+	report, err := h.service.ReconcileEventStock(c.Context(), eventID, c.QueryBool("repair"))
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	tiers := make([]TierDriftResponse, len(report.Tiers))
+	for i, tier := range report.Tiers {
+		tiers[i] = TierDriftResponse{
+			TierID:   tier.TierID,
+			Expected: tier.Expected,
+			Database: tier.Database,
+			Cached:   tier.Cached,
+			Drifted:  tier.Drifted(),
+			Repaired: tier.Repaired,
+		}
+	}
+
+	response := StockReportResponse{
+		EventID: report.EventID,
+		Drifted: report.Drifted(),
+		Tiers:   tiers,
+	}
+
+	return responses.Success(c, response, "success")
+}
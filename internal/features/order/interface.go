@@ -3,6 +3,9 @@ package order
 import (
 	"context"
 	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/payment"
+	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,16 +14,87 @@ type Usecase interface {
 	CreateOrder(ctx context.Context, order domain.Order) (*domain.Order, error)
 	GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error)
 	GetOrderList(ctx context.Context) ([]domain.Order, error)
+	GetOrderStatusHistory(ctx context.Context, bookingID string) ([]domain.OrderStatusHistory, error)
+	CancelOrder(ctx context.Context, bookingID string) (*domain.Order, error)
 }
 
 type Repository interface {
-	CreateOrder(ctx context.Context, order *domain.Order) error
+	CreateOrder(ctx context.Context, order *domain.Order, maxPerUser int) error
+	CountUserTickets(ctx context.Context, userID, eventID uuid.UUID) (int, error)
 	GetEventByID(ctx context.Context, eventID uuid.UUID) (*domain.Event, error)
+	GetTierByID(ctx context.Context, tierID uuid.UUID) (*domain.TicketTier, error)
+	GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error)
 	GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error)
 	GetOrderList(ctx context.Context, userID uuid.UUID) ([]domain.Order, error)
-	UpdateOrderStatus(ctx context.Context, bookingID string, status domain.OrderStatus) error
+	TransitionOrderStatus(ctx context.Context, bookingID string, to domain.OrderStatus, change StatusChange, messages ...domain.OutboxMessage) (*domain.Order, error)
+	CompleteOrder(ctx context.Context, bookingID string, change StatusChange, messages ...domain.OutboxMessage) (*domain.Order, error)
+	GetOrderStatusHistory(ctx context.Context, orderID uuid.UUID) ([]domain.OrderStatusHistory, error)
+	GetExpiredPendingOrders(ctx context.Context, now time.Time, limit int) ([]domain.Order, error)
+	ReleaseOrder(ctx context.Context, bookingID string, to domain.OrderStatus, change StatusChange, messages ...domain.OutboxMessage) (*domain.Order, error)
+	UpdatePaymentDetails(ctx context.Context, orderID uuid.UUID, provider string, charge payment.Charge) error
+	GetReconcilableEventIDs(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+	GetTierStock(ctx context.Context, eventID uuid.UUID) ([]TierStock, error)
+	RepairTierStock(ctx context.Context, eventID, tierID uuid.UUID) (*TierStock, error)
 }
 
 type Service interface {
-	ProcessPaymentWebhook(ctx context.Context, payload PaymentWebhookRequest) error
+	ProcessPaymentWebhook(ctx context.Context, body []byte, headers http.Header) error
+	SyncPaymentStatus(ctx context.Context, bookingID string) error
+	RefundOrder(ctx context.Context, bookingID string, reason string) (*domain.Order, error)
+	RequeueOrder(ctx context.Context, bookingID string, reason string) (*domain.Order, error)
+	ReconcileEventStock(ctx context.Context, eventID uuid.UUID, repair bool) (*StockReport, error)
+}
+
+// TierStock is a tier's stock column next to the seats its orders hold
+type TierStock struct {
+	TierID         uuid.UUID
+	TotalStock     int
+	AvailableStock int
+	Held           int
+}
+
+// Expected is the available stock the tier's orders add up to
+func (t TierStock) Expected() int {
+	return t.TotalStock - t.Held
+}
+
+// TierDrift compares a tier's stock in Postgres and Redis with what its
+// orders add up to. Cached is nil when the tier isn't cached right now.
+type TierDrift struct {
+	TierID   uuid.UUID
+	Expected int
+	Database int
+	Cached   *int
+	Repaired bool
+}
+
+func (d TierDrift) Drifted() bool {
+	return d.Database != d.Expected || (d.Cached != nil && *d.Cached != d.Expected)
+}
+
+type StockReport struct {
+	EventID uuid.UUID
+	Tiers   []TierDrift
+}
+
+func (r StockReport) Drifted() bool {
+	for _, tier := range r.Tiers {
+		if tier.Drifted() {
+			return true
+		}
+	}
+	return false
+}
+
+// PaymentProvider abstracts the payment gateway used to charge and confirm orders
+type PaymentProvider interface {
+	Name() string
+	CreateCharge(ctx context.Context, req payment.ChargeRequest) (*payment.Charge, error)
+	// ParseWebhook verifies the notification signature before trusting its content
+	ParseWebhook(ctx context.Context, body []byte, headers http.Header) (*payment.Notification, error)
+	GetStatus(ctx context.Context, orderID string) (*payment.Notification, error)
+	Refund(ctx context.Context, req payment.RefundRequest) error
+	// ChargedAmount is what the provider actually bills for amount, after
+	// any rounding it applies when the charge is created
+	ChargedAmount(amount domain.Money) domain.Money
 }
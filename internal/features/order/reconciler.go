@@ -0,0 +1,240 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultStockReconcileInterval = 5 * time.Minute
+	// stockDriftSettle is how long to wait before looking at a drifted tier
+	// again. Redis runs ahead of Postgres for the moment between reserving
+	// seats and committing the order, only drift that outlives that counts.
+	stockDriftSettle = 2 * time.Second
+	// reconcileLookback keeps events that started recently in the sweep
+	reconcileLookback = 24 * time.Hour
+)
+
+// repairCacheScript sets the cached stock in KEYS[1] to ARGV[2], but only if
+// it still holds ARGV[1] so seats taken in the meantime aren't overwritten
+var repairCacheScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+return 1
+`)
+
+// ReconcileEventStock recomputes every tier's available stock from the
+// orders in Postgres and compares it with the tier column and the Redis
+// counter. With repair set, tiers that stay drifted are rewritten to match
+// the orders.
+func (s *service) ReconcileEventStock(ctx context.Context, eventID uuid.UUID, repair bool) (*StockReport, error) {
+	event, err := s.repo.GetEventByID(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.ID == uuid.Nil {
+		return nil, domain.ErrEventNotFound
+	}
+
+	first, err := s.inspectTiers(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &StockReport{EventID: eventID, Tiers: first}
+	if !report.Drifted() {
+		return report, nil
+	}
+
+	time.Sleep(stockDriftSettle)
+
+	second, err := s.inspectTiers(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+	report.Tiers = second
+
+	for i := range report.Tiers {
+		drift := &report.Tiers[i]
+		if !drift.Drifted() {
+			continue
+		}
+
+		if !sameDrift(first, *drift) {
+			s.log.Infof("Stock of tier %s on event %s is still moving, leaving it for the next run", drift.TierID, eventID)
+			continue
+		}
+
+		s.log.Warnf("Stock drift on event %s tier %s: expected %d, database %d, cache %s",
+			eventID, drift.TierID, drift.Expected, drift.Database, cachedString(drift.Cached))
+
+		if repair {
+			s.repairTier(ctx, eventID, drift)
+		}
+	}
+
+	return report, nil
+}
+
+func (s *service) inspectTiers(ctx context.Context, eventID uuid.UUID) ([]TierDrift, error) {
+	stock, err := s.repo.GetTierStock(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	drifts := make([]TierDrift, len(stock))
+	for i, tier := range stock {
+		drifts[i] = TierDrift{
+			TierID:   tier.TierID,
+			Expected: tier.Expected(),
+			Database: tier.AvailableStock,
+		}
+
+		cached, err := s.cache.Get(ctx, stockKey(eventID, tier.TierID)).Int()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read cached stock: %w", err)
+		}
+		drifts[i].Cached = &cached
+	}
+
+	return drifts, nil
+}
+
+func (s *service) repairTier(ctx context.Context, eventID uuid.UUID, drift *TierDrift) {
+	if drift.Database != drift.Expected {
+		repaired, err := s.repo.RepairTierStock(ctx, eventID, drift.TierID)
+		if err != nil {
+			s.log.Errorf("failed to repair stock of tier %s: %v", drift.TierID, err)
+			return
+		}
+
+		drift.Expected = repaired.Expected()
+		drift.Database = repaired.AvailableStock
+	}
+
+	if drift.Cached != nil && *drift.Cached != drift.Expected {
+		keys := []string{stockKey(eventID, drift.TierID)}
+		set, err := repairCacheScript.Run(ctx, s.cache, keys, *drift.Cached, drift.Expected).Int()
+		if err != nil {
+			s.log.Errorf("failed to repair cached stock of tier %s: %v", drift.TierID, err)
+			return
+		}
+
+		if set == 1 {
+			cached := drift.Expected
+			drift.Cached = &cached
+		}
+	}
+
+	drift.Repaired = !drift.Drifted()
+	if drift.Repaired {
+		s.log.Infof("Repaired stock of tier %s on event %s to %d", drift.TierID, eventID, drift.Expected)
+	}
+}
+
+// sameDrift reports whether the tier looked exactly the same in an earlier
+// inspection
+func sameDrift(earlier []TierDrift, drift TierDrift) bool {
+	for _, d := range earlier {
+		if d.TierID != drift.TierID {
+			continue
+		}
+
+		sameCache := (d.Cached == nil && drift.Cached == nil) ||
+			(d.Cached != nil && drift.Cached != nil && *d.Cached == *drift.Cached)
+
+		return sameCache && d.Database == drift.Database && d.Expected == drift.Expected
+	}
+
+	return false
+}
+
+func cachedString(cached *int) string {
+	if cached == nil {
+		return "not cached"
+	}
+	return fmt.Sprint(*cached)
+}
+
+// StockReconciler periodically looks for drift between Redis, the stock
+// columns and the orders of upcoming events
+type StockReconciler struct {
+	repo Repository
+	svc  Service
+	cfg  configs.Config
+	log  *zap.SugaredLogger
+}
+
+func NewStockReconciler(
+	repo Repository,
+	svc Service,
+	cfg configs.Config,
+	log *zap.SugaredLogger,
+) *StockReconciler {
+	return &StockReconciler{
+		repo: repo,
+		svc:  svc,
+		cfg:  cfg,
+		log:  log.Named("StockReconciler"),
+	}
+}
+
+// Start reconciles stock every interval until ctx is cancelled
+func (r *StockReconciler) Start(ctx context.Context) {
+	interval := r.cfg.StockReconcileInterval
+	if interval <= 0 {
+		interval = defaultStockReconcileInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.reconcile(ctx)
+	}
+}
+
+func (r *StockReconciler) reconcile(ctx context.Context) {
+	eventIDs, err := r.repo.GetReconcilableEventIDs(ctx, time.Now().Add(-reconcileLookback))
+	if err != nil {
+		r.log.Errorf("failed to get events to reconcile: %v", err)
+		return
+	}
+
+	drifted := 0
+	for _, eventID := range eventIDs {
+		report, err := r.svc.ReconcileEventStock(ctx, eventID, r.cfg.StockReconcileRepair)
+		if err != nil {
+			r.log.Errorf("failed to reconcile stock of event %s: %v", eventID, err)
+			continue
+		}
+
+		if report.Drifted() {
+			drifted++
+		}
+	}
+
+	if drifted > 0 {
+		r.log.Warnf("Stock drift found on %d of %d events", drifted, len(eventIDs))
+	}
+}
@@ -2,10 +2,15 @@ package order
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/payment"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type repository struct {
@@ -16,13 +21,59 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) CreateOrder(ctx context.Context, order *domain.Order) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+// heldStatuses are the order states that still hold their seats, and so
+// count towards the tier's sold stock and the user's purchase limit
+var heldStatuses = []domain.OrderStatus{
+	domain.OrderStatusPending,
+	domain.OrderStatusPaid,
+	domain.OrderStatusProcessing,
+	domain.OrderStatusCompleted,
+	domain.OrderStatusFailed,
+	domain.OrderStatusRefunding,
+}
+
+// CreateOrder inserts the order and takes its seats. When maxPerUser is set,
+// the user's existing orders for the event are counted under an advisory
+// lock, so parallel requests from one account can't both slip under it.
+func (r *repository) CreateOrder(ctx context.Context, order *domain.Order, maxPerUser int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if maxPerUser > 0 {
+			lockKey := order.UserID.String() + ":" + order.EventID.String()
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+				return err
+			}
+
+			held, err := countUserTickets(tx, order.UserID, order.EventID)
+			if err != nil {
+				return err
+			}
+
+			if held+order.Quantity > maxPerUser {
+				return domain.ErrPurchaseLimitExceeded
+			}
+		}
+
 		// Create order
 		if err := tx.Create(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errBookingIDTaken
+			}
 			return err
 		}
 
+		// Guard against overselling the tier even if the cache drifted
+		tierResult := tx.Model(&domain.TicketTier{}).
+			Where("id = ? AND available_stock >= ?", order.TierID, order.Quantity).
+			UpdateColumn("available_stock", gorm.Expr("available_stock - ?", order.Quantity))
+
+		if tierResult.Error != nil {
+			return tierResult.Error
+		}
+
+		if tierResult.RowsAffected == 0 {
+			return domain.ErrNotEnoughStock
+		}
+
 		result := tx.Model(&domain.Event{}).
 			Where("id = ?", order.EventID).
 			UpdateColumn("available_stock", gorm.Expr("available_stock - ?", order.Quantity))
@@ -39,16 +90,107 @@ func (r *repository) CreateOrder(ctx context.Context, order *domain.Order) error
 			return err
 		}
 
+		if err := tx.First(&order.Tier, order.TierID).Error; err != nil {
+			return err
+		}
+
 		return nil
 	})
 }
 
+func (r *repository) CountUserTickets(ctx context.Context, userID, eventID uuid.UUID) (int, error) {
+	return countUserTickets(r.db.WithContext(ctx), userID, eventID)
+}
+
+func countUserTickets(db *gorm.DB, userID, eventID uuid.UUID) (int, error) {
+	var held int64
+
+	err := db.Model(&domain.Order{}).
+		Where("user_id = ? AND event_id = ? AND status IN ?", userID, eventID, heldStatuses).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&held).Error
+
+	return int(held), err
+}
+
+func (r *repository) GetReconcilableEventIDs(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+
+	err := r.db.WithContext(ctx).Model(&domain.Event{}).
+		Where("date >= ?", since).
+		Pluck("id", &ids).Error
+
+	return ids, err
+}
+
+func (r *repository) GetTierStock(ctx context.Context, eventID uuid.UUID) ([]TierStock, error) {
+	return tierStock(r.db.WithContext(ctx), "event_id = ?", eventID)
+}
+
+// RepairTierStock rewrites a tier's available stock from its orders and
+// refreshes the event totals. The tier row is locked first, so orders that
+// commit while it runs are applied on top of the repaired value.
+func (r *repository) RepairTierStock(ctx context.Context, eventID, tierID uuid.UUID) (*TierStock, error) {
+	var repaired TierStock
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tier domain.TicketTier
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND event_id = ?", tierID, eventID).
+			First(&tier).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrTierNotFound
+			}
+			return err
+		}
+
+		stock, err := tierStock(tx, "id = ?", tierID)
+		if err != nil {
+			return err
+		}
+		repaired = stock[0]
+		repaired.AvailableStock = repaired.Expected()
+
+		if err := tx.Model(&tier).UpdateColumn("available_stock", repaired.AvailableStock).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.Event{}).
+			Where("id = ?", eventID).
+			UpdateColumn("available_stock", tx.Model(&domain.TicketTier{}).
+				Where("event_id = ?", eventID).
+				Select("SUM(available_stock)")).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &repaired, nil
+}
+
+func tierStock(db *gorm.DB, query string, args ...interface{}) ([]TierStock, error) {
+	var stock []TierStock
+
+	held := db.Session(&gorm.Session{NewDB: true}).Model(&domain.Order{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("orders.tier_id = ticket_tiers.id AND orders.status IN ?", heldStatuses)
+
+	err := db.Model(&domain.TicketTier{}).
+		Select("ticket_tiers.id AS tier_id, ticket_tiers.total_stock, ticket_tiers.available_stock, (?) AS held", held).
+		Where(query, args...).
+		Order("ticket_tiers.price ASC").
+		Scan(&stock).Error
+
+	return stock, err
+}
+
 func (r *repository) GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error) {
 	var order domain.Order
 
 	if err := r.db.Model(&domain.Order{}).
 		Where("booking_id = ?", bookingID).
 		Preload("Event").
+		Preload("Tier").
 		Preload("Ticket").
 		Find(&order).Error; err != nil {
 		return nil, err
@@ -62,6 +204,7 @@ func (r *repository) GetOrderList(ctx context.Context, userID uuid.UUID) ([]doma
 	if err := r.db.Model(&domain.Order{}).
 		Where("user_id = ?", userID).
 		Preload("Event").
+		Preload("Tier").
 		Order("created_at DESC").
 		Find(&orders).
 		Error; err != nil {
@@ -79,8 +222,227 @@ func (r *repository) GetEventByID(ctx context.Context, eventID uuid.UUID) (*doma
 	return &event, nil
 }
 
-func (r *repository) UpdateOrderStatus(ctx context.Context, bookingID string, status domain.OrderStatus) error {
-	return r.db.Model(&domain.Order{}).
-		Where("booking_id = ?", bookingID).
-		Update("status", status).Error
+func (r *repository) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	var user domain.User
+
+	err := r.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *repository) GetTierByID(ctx context.Context, tierID uuid.UUID) (*domain.TicketTier, error) {
+	var tier domain.TicketTier
+
+	err := r.db.WithContext(ctx).Where("id = ?", tierID).First(&tier).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tier, nil
+}
+
+// TransitionOrderStatus moves an order to a new status if the state machine
+// allows it and records the change in the status history. messages are saved
+// to the outbox in the same transaction, so they go out only if it commits.
+func (r *repository) TransitionOrderStatus(ctx context.Context, bookingID string, to domain.OrderStatus, change StatusChange, messages ...domain.OutboxMessage) (*domain.Order, error) {
+	var order domain.Order
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", bookingID).
+			First(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrOrderNotFound
+			}
+			return err
+		}
+
+		if err := transitionStatus(tx, &order, to, change); err != nil {
+			return err
+		}
+
+		return enqueueMessages(tx, messages)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// CompleteOrder marks an order COMPLETED once every ticket slot 1..Quantity
+// is filled, counting them under the order's row lock. An order that is
+// already COMPLETED is returned as is, so a redelivered job is a no-op.
+func (r *repository) CompleteOrder(ctx context.Context, bookingID string, change StatusChange, messages ...domain.OutboxMessage) (*domain.Order, error) {
+	var order domain.Order
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", bookingID).
+			First(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrOrderNotFound
+			}
+			return err
+		}
+
+		if order.Status == domain.OrderStatusCompleted {
+			return nil
+		}
+
+		var issued int64
+		if err := tx.Model(&domain.Ticket{}).
+			Where("order_id = ? AND seq BETWEEN 1 AND ?", order.ID, order.Quantity).
+			Count(&issued).Error; err != nil {
+			return err
+		}
+		if issued != int64(order.Quantity) {
+			return fmt.Errorf("%w: %d of %d issued", domain.ErrTicketsIncomplete, issued, order.Quantity)
+		}
+
+		if err := transitionStatus(tx, &order, domain.OrderStatusCompleted, change); err != nil {
+			return err
+		}
+
+		return enqueueMessages(tx, messages)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func (r *repository) GetOrderStatusHistory(ctx context.Context, orderID uuid.UUID) ([]domain.OrderStatusHistory, error) {
+	var history []domain.OrderStatusHistory
+
+	if err := r.db.WithContext(ctx).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// transitionStatus validates and applies a status change on a locked order
+// row and appends it to the history, all within the caller's transaction
+func transitionStatus(tx *gorm.DB, order *domain.Order, to domain.OrderStatus, change StatusChange) error {
+	from := order.Status
+	if err := ValidateTransition(from, to); err != nil {
+		return err
+	}
+
+	// The failure reason is kept for as long as the order stays FAILED
+	updates := map[string]interface{}{"status": to}
+	if to == domain.OrderStatusFailed {
+		updates["failure_reason"] = change.Reason
+	} else if from == domain.OrderStatusFailed {
+		updates["failure_reason"] = ""
+	}
+
+	if err := tx.Model(order).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	history := domain.OrderStatusHistory{
+		OrderID:    order.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      change.Actor,
+		Reason:     change.Reason,
+	}
+
+	return tx.Create(&history).Error
+}
+
+func enqueueMessages(tx *gorm.DB, messages []domain.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return tx.Create(&messages).Error
+}
+
+func (r *repository) GetExpiredPendingOrders(ctx context.Context, now time.Time, limit int) ([]domain.Order, error) {
+	var orders []domain.Order
+
+	if err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", domain.OrderStatusPending, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ReleaseOrder moves an order to a status that gives its seats back
+// (EXPIRED, CANCELLED or REFUNDED), returns the stock to the event and revokes
+// any tickets that were not used yet, all in one transaction. messages are
+// saved to the outbox in the same transaction. The cached stock is left to
+// the caller, see releaseOrderInRedis. The returned order has its tickets
+// loaded.
+func (r *repository) ReleaseOrder(ctx context.Context, bookingID string, to domain.OrderStatus, change StatusChange, messages ...domain.OutboxMessage) (*domain.Order, error) {
+	var order domain.Order
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("booking_id = ?", bookingID).
+			First(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrOrderNotFound
+			}
+			return err
+		}
+
+		if err := transitionStatus(tx, &order, to, change); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.TicketTier{}).
+			Where("id = ?", order.TierID).
+			UpdateColumn("available_stock", gorm.Expr("available_stock + ?", order.Quantity)).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Event{}).
+			Where("id = ?", order.EventID).
+			UpdateColumn("available_stock", gorm.Expr("available_stock + ?", order.Quantity)).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&domain.Ticket{}).
+			Where("order_id = ? AND status = ?", order.ID, domain.TicketStatusValid).
+			Update("status", domain.TicketStatusRevoked).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("order_id = ?", order.ID).Find(&order.Ticket).Error; err != nil {
+			return err
+		}
+
+		return enqueueMessages(tx, messages)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+func (r *repository) UpdatePaymentDetails(ctx context.Context, orderID uuid.UUID, provider string, charge payment.Charge) error {
+	return r.db.WithContext(ctx).Model(&domain.Order{}).
+		Where("id = ?", orderID).
+		Updates(map[string]interface{}{
+			"payment_provider":  provider,
+			"payment_reference": charge.Reference,
+			"payment_url":       charge.RedirectURL,
+		}).Error
 }
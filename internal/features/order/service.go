@@ -2,61 +2,310 @@ package order
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/domain"
-	rabbitmq "go-war-ticket-service/internal/platform/message_broker/rabbit_mq"
+	"go-war-ticket-service/internal/platform/payment"
+	"go-war-ticket-service/internal/platform/storage"
 	"go-war-ticket-service/internal/utils"
+	"go-war-ticket-service/internal/utils/contextutil"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
 type service struct {
-	repo Repository
-	log  *zap.SugaredLogger
-	mq   rabbitmq.Publisher
+	repo        Repository
+	log         *zap.SugaredLogger
+	provider    PaymentProvider
+	cache       *redis.Client
+	minioClient *minio.Client
+	cfg         configs.Config
 }
 
 func NewService(
 	repo Repository,
 	log *zap.SugaredLogger,
-	mq rabbitmq.Publisher,
+	provider PaymentProvider,
+	cache *redis.Client,
+	minioClient *minio.Client,
+	cfg configs.Config,
 ) Service {
 	return &service{
-		repo: repo,
-		log:  log.Named("OrderService"),
-		mq:   mq,
+		repo:        repo,
+		log:         log.Named("OrderService"),
+		provider:    provider,
+		cache:       cache,
+		minioClient: minioClient,
+		cfg:         cfg,
 	}
 }
 
-func (s *service) ProcessPaymentWebhook(ctx context.Context, payload PaymentWebhookRequest) error {
-	if payload.PaymentStatus != "PAID" && payload.PaymentStatus != "SETTLEMENT" {
-		s.log.Info("Payment status not paid, ignoring...")
+func (s *service) ProcessPaymentWebhook(ctx context.Context, body []byte, headers http.Header) error {
+	notification, err := s.provider.ParseWebhook(ctx, body, headers)
+	if err != nil {
+		s.log.Warnf("rejected %s payment webhook: %v", s.provider.Name(), err)
+		return err
+	}
+
+	return s.applyNotification(ctx, notification)
+}
+
+// SyncPaymentStatus asks the provider for the latest payment status, to catch
+// payments whose webhook never reached us
+func (s *service) SyncPaymentStatus(ctx context.Context, bookingID string) error {
+	notification, err := s.provider.GetStatus(ctx, bookingID)
+	if err != nil {
+		return fmt.Errorf("failed to query payment status: %w", err)
+	}
+
+	return s.applyNotification(ctx, notification)
+}
+
+// RefundOrder refunds a paid order through the payment provider, then
+// releases its seats and revokes its tickets. The order is moved to
+// REFUNDING before any money moves, so a refund interrupted after the
+// provider call is never mistaken for a live order and can be retried.
+func (s *service) RefundOrder(ctx context.Context, bookingID string, reason string) (*domain.Order, error) {
+	order, err := s.getOrder(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	actorID, _ := contextutil.GetUserID(ctx)
+	change := StatusChange{Actor: UserActor(actorID), Reason: reason}
+
+	if order.Status != domain.OrderStatusRefunding {
+		if _, err := s.repo.TransitionOrderStatus(ctx, order.BookingID, domain.OrderStatusRefunding, change); err != nil {
+			return nil, err
+		}
+	}
+
+	// The provider dedupes on the order, so retrying a REFUNDING order
+	// never pays out twice
+	if err := s.provider.Refund(ctx, payment.RefundRequest{
+		OrderID: order.BookingID,
+		Amount:  order.TotalPrice,
+		Reason:  reason,
+	}); err != nil {
+		s.log.Errorf("failed to refund order %s with %s, it stays REFUNDING: %v", order.BookingID, s.provider.Name(), err)
+		return nil, domain.ErrPaymentUnavailable
+	}
+
+	return s.refund(ctx, order, change)
+}
+
+// RequeueOrder sends a FAILED order back to ticket generation. The order
+// returns to PAID, clearing its failure reason, and the job is queued
+// through the outbox in the same transaction.
+func (s *service) RequeueOrder(ctx context.Context, bookingID string, reason string) (*domain.Order, error) {
+	order, err := s.getOrder(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != domain.OrderStatusFailed {
+		return nil, domain.ErrOrderNotFailed
+	}
+
+	message, err := domain.NewOutboxMessage(utils.QueueTicketGeneration, map[string]interface{}{
+		"booking_id": order.BookingID,
+		"status":     domain.OrderStatusPaid,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reason == "" {
+		reason = "requeued for ticket generation"
+	}
+
+	actorID, _ := contextutil.GetUserID(ctx)
+	return s.repo.TransitionOrderStatus(ctx, order.BookingID, domain.OrderStatusPaid, StatusChange{Actor: UserActor(actorID), Reason: reason}, message)
+}
+
+func (s *service) applyNotification(ctx context.Context, notification *payment.Notification) error {
+	switch notification.Status {
+	case payment.StatusPaid:
+		return s.markPaid(ctx, notification)
+	case payment.StatusRefunded:
+		return s.markRefunded(ctx, notification)
+	default:
+		s.log.Infof("Payment status %s for order %s not paid, ignoring...", notification.RawStatus, notification.OrderID)
 		return nil
 	}
+}
 
-	order, err := s.repo.GetOrderByBookingID(ctx, payload.BookingID)
+func (s *service) markPaid(ctx context.Context, notification *payment.Notification) error {
+	order, err := s.getOrder(ctx, notification.OrderID)
 	if err != nil {
 		return err
 	}
 
-	if order.Status == domain.OrderStatusPaid || order.Status == domain.OrderStatusCompleted {
+	switch order.Status {
+	case domain.OrderStatusPaid, domain.OrderStatusProcessing, domain.OrderStatusCompleted, domain.OrderStatusFailed, domain.OrderStatusRefunding:
 		s.log.Info("Order already paid, ignoring...")
 		return nil
 	}
 
-	if err := s.repo.UpdateOrderStatus(ctx, order.BookingID, domain.OrderStatusPaid); err != nil {
+	if order.Status == domain.OrderStatusExpired || order.Status == domain.OrderStatusCancelled {
+		s.refundLatePayment(ctx, order, notification)
+		return nil
+	}
+
+	if err := s.verifyAmount(order, notification); err != nil {
 		return err
 	}
 
-	body := map[string]interface{}{
+	// Ticket generation is queued through the outbox, in the same transaction
+	// that marks the order PAID
+	message, err := domain.NewOutboxMessage(utils.QueueTicketGeneration, map[string]interface{}{
 		"booking_id": order.BookingID,
-		"status":     order.Status,
+		"status":     domain.OrderStatusPaid,
+	})
+	if err != nil {
+		return err
 	}
 
-	err = s.mq.Publish(ctx, utils.QueueTicketGeneration, body)
+	change := StatusChange{Actor: ActorPaymentWebhook, Reason: fmt.Sprintf("%s payment status %s", s.provider.Name(), notification.RawStatus)}
+	if _, err := s.repo.TransitionOrderStatus(ctx, order.BookingID, domain.OrderStatusPaid, change, message); err != nil {
+		// A concurrent webhook or the expiry sweeper got there first
+		if errors.Is(err, domain.ErrInvalidOrderTransition) {
+			s.log.Warnf("Ignoring payment for order %s: %v", notification.OrderID, err)
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// refundLatePayment gives the money back for an order whose seats were
+// already released. The webhook is still acknowledged, as the provider would
+// otherwise redeliver it forever, so a failed refund is left to an operator.
+func (s *service) refundLatePayment(ctx context.Context, order *domain.Order, notification *payment.Notification) {
+	s.log.Warnf("Payment received for %s order %s, refunding it", order.Status, order.BookingID)
+
+	if err := s.provider.Refund(ctx, payment.RefundRequest{
+		OrderID: order.BookingID,
+		Amount:  order.TotalPrice,
+		Reason:  fmt.Sprintf("payment received after the order was %s", strings.ToLower(string(order.Status))),
+	}); err != nil {
+		s.log.Errorf("MANUAL ACTION REQUIRED: failed to refund late %s payment %s for %s order %s: %v",
+			s.provider.Name(), notification.RawStatus, order.Status, order.BookingID, err)
+		return
+	}
+
+	s.log.Infof("Late payment for %s order %s refunded", order.Status, order.BookingID)
+}
+
+// markRefunded handles refunds started from the provider's dashboard
+func (s *service) markRefunded(ctx context.Context, notification *payment.Notification) error {
+	order, err := s.getOrder(ctx, notification.OrderID)
 	if err != nil {
-		s.log.Errorf("failed to publish message: %v", err)
 		return err
 	}
 
+	if order.Status == domain.OrderStatusRefunded {
+		s.log.Info("Order already refunded, ignoring...")
+		return nil
+	}
+
+	change := StatusChange{Actor: ActorPaymentWebhook, Reason: fmt.Sprintf("refunded at %s", s.provider.Name())}
+	if _, err := s.refund(ctx, order, change); err != nil {
+		if errors.Is(err, domain.ErrInvalidOrderTransition) {
+			s.log.Warnf("Ignoring refund for order %s: %v", notification.OrderID, err)
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// refund releases the order's seats, revokes its tickets, removes their PDFs
+// and tells downstream systems about it through the outbox
+func (s *service) refund(ctx context.Context, order *domain.Order, change StatusChange) (*domain.Order, error) {
+	message, err := domain.NewOutboxMessage(utils.QueueOrderRefunded, map[string]interface{}{
+		"booking_id":  order.BookingID,
+		"order_id":    order.ID,
+		"user_id":     order.UserID,
+		"event_id":    order.EventID,
+		"quantity":    order.Quantity,
+		"amount":      order.TotalPrice,
+		"currency":    order.Currency,
+		"reason":      change.Reason,
+		"refunded_by": change.Actor,
+		"refunded_at": time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refunded, err := s.repo.ReleaseOrder(ctx, order.BookingID, domain.OrderStatusRefunded, change, message)
+	if err != nil {
+		s.log.Errorf("failed to release refunded order %s: %v", order.BookingID, err)
+		return nil, err
+	}
+
+	if err := releaseOrderInRedis(ctx, s.cache, *refunded); err != nil {
+		s.log.Warnf("failed to release cached seats of order %s: %v", order.BookingID, err)
+	}
+
+	// Revoked PDFs are removed so previously shared links stop working
+	for _, ticket := range refunded.Ticket {
+		if ticket.Status != domain.TicketStatusRevoked || ticket.PDFUrl == "" {
+			continue
+		}
+
+		if err := storage.DeleteObjectFromMinIO(s.minioClient, s.cfg.MinioBucket, ticket.PDFUrl); err != nil {
+			s.log.Errorf("failed to delete ticket PDF %s: %v", ticket.PDFUrl, err)
+		}
+	}
+
+	refunded.Event = order.Event
+	refunded.Tier = order.Tier
+	return refunded, nil
+}
+
+func (s *service) getOrder(ctx context.Context, bookingID string) (*domain.Order, error) {
+	order, err := s.repo.GetOrderByBookingID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.ID == uuid.Nil {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+// verifyAmount makes sure a signed notification was for the full order
+// total, as the provider rounded it when the charge was created. A
+// notification that doesn't say how much was paid is not trusted.
+func (s *service) verifyAmount(order *domain.Order, notification *payment.Notification) error {
+	if notification.GrossAmount == "" {
+		s.log.Warnf("payment notification for order %s carries no amount", order.BookingID)
+		return domain.ErrPaymentAmountMismatch
+	}
+
+	paid, err := domain.ParseMoney(notification.GrossAmount)
+	if err != nil {
+		s.log.Warnf("invalid gross amount %q for order %s", notification.GrossAmount, order.BookingID)
+		return domain.ErrPaymentAmountMismatch
+	}
+
+	if expected := s.provider.ChargedAmount(order.TotalPrice); paid != expected {
+		s.log.Warnf("payment amount %s does not match order %s total %s", notification.GrossAmount, order.BookingID, expected)
+		return domain.ErrPaymentAmountMismatch
+	}
+
 	return nil
 }
@@ -0,0 +1,94 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/payment"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+func TestVerifyAmount(t *testing.T) {
+	midtrans := &service{provider: payment.NewMidtransProvider("server-key", false), log: zap.NewNop().Sugar()}
+	fake := &service{provider: payment.NewFakeProvider("secret"), log: zap.NewNop().Sugar()}
+
+	tests := []struct {
+		name    string
+		svc     *service
+		total   domain.Money
+		gross   string
+		wantErr bool
+	}{
+		{name: "exact total", svc: fake, total: 15000050, gross: "150000.50"},
+		{name: "midtrans rounds a fractional total", svc: midtrans, total: 15000050, gross: "150001.00"},
+		{name: "midtrans whole total", svc: midtrans, total: 15000000, gross: "150000.00"},
+		{name: "underpaid", svc: midtrans, total: 15000000, gross: "149999.00", wantErr: true},
+		{name: "unrounded amount against midtrans", svc: midtrans, total: 15000050, gross: "150000.50", wantErr: true},
+		{name: "missing amount", svc: fake, total: 15000000, gross: "", wantErr: true},
+		{name: "garbage amount", svc: fake, total: 15000000, gross: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &domain.Order{BookingID: "WT-TEST", TotalPrice: tt.total}
+			err := tt.svc.verifyAmount(order, &payment.Notification{OrderID: order.BookingID, GrossAmount: tt.gross})
+
+			if tt.wantErr && !errors.Is(err, domain.ErrPaymentAmountMismatch) {
+				t.Fatalf("expected ErrPaymentAmountMismatch, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// refundingProvider records refunds and embeds the fake for everything else
+type refundingProvider struct {
+	*payment.FakeProvider
+	refunds []payment.RefundRequest
+	err     error
+}
+
+func (p *refundingProvider) Refund(ctx context.Context, req payment.RefundRequest) error {
+	p.refunds = append(p.refunds, req)
+	return p.err
+}
+
+// orderRepo returns one order, every other Repository method panics through
+// the nil embedded interface
+type orderRepo struct {
+	Repository
+	order domain.Order
+}
+
+func (r *orderRepo) GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error) {
+	return &r.order, nil
+}
+
+func TestLatePaymentIsRefunded(t *testing.T) {
+	for _, status := range []domain.OrderStatus{domain.OrderStatusExpired, domain.OrderStatusCancelled} {
+		for _, refundErr := range []error{nil, errors.New("provider down")} {
+			provider := &refundingProvider{FakeProvider: payment.NewFakeProvider("secret"), err: refundErr}
+			repo := &orderRepo{order: domain.Order{
+				BaseModel:  domain.BaseModel{ID: uuid.New()},
+				BookingID:  "WT-TEST",
+				Status:     status,
+				TotalPrice: 15000000,
+			}}
+			s := &service{repo: repo, provider: provider, log: zap.NewNop().Sugar()}
+
+			// Acknowledged either way, so the provider stops redelivering it
+			err := s.markPaid(context.Background(), &payment.Notification{OrderID: "WT-TEST", Status: payment.StatusPaid, GrossAmount: "150000.00"})
+			if err != nil {
+				t.Fatalf("%s order, refund error %v: expected the webhook to be acknowledged, got %v", status, refundErr, err)
+			}
+			if len(provider.refunds) != 1 || provider.refunds[0].Amount != 15000000 {
+				t.Fatalf("%s order: expected one full refund, got %+v", status, provider.refunds)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package order
+
+import (
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Actors recorded in the order status history for system initiated changes
+const (
+	ActorPaymentWebhook  = "system:payment-webhook"
+	ActorTicketWorker    = "system:ticket-worker"
+	ActorExpirySweeper   = "system:expiry-sweeper"
+	ActorPaymentProvider = "system:payment-provider"
+)
+
+// UserActor identifies a user initiated status change in the history
+func UserActor(userID uuid.UUID) string {
+	return "user:" + userID.String()
+}
+
+// StatusChange describes who moved an order to a new status and why
+type StatusChange struct {
+	Actor  string
+	Reason string
+}
+
+// orderTransitions lists, for every status, the statuses an order may move to next
+var orderTransitions = map[domain.OrderStatus][]domain.OrderStatus{
+	domain.OrderStatusPending:    {domain.OrderStatusPaid, domain.OrderStatusExpired, domain.OrderStatusCancelled},
+	domain.OrderStatusPaid:       {domain.OrderStatusProcessing, domain.OrderStatusFailed, domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+	domain.OrderStatusProcessing: {domain.OrderStatusCompleted, domain.OrderStatusFailed, domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+	domain.OrderStatusCompleted:  {domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+	domain.OrderStatusFailed:     {domain.OrderStatusPaid, domain.OrderStatusProcessing, domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+	domain.OrderStatusRefunding:  {domain.OrderStatusRefunded},
+}
+
+// CanTransition reports whether an order may move from one status to another
+func CanTransition(from, to domain.OrderStatus) bool {
+	for _, next := range orderTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns ErrInvalidOrderTransition when the move is not allowed
+func ValidateTransition(from, to domain.OrderStatus) error {
+	if !CanTransition(from, to) {
+		return fmt.Errorf("%w: %s -> %s", domain.ErrInvalidOrderTransition, from, to)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package order
+
+import (
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"testing"
+)
+
+var allOrderStatuses = []domain.OrderStatus{
+	domain.OrderStatusPending,
+	domain.OrderStatusPaid,
+	domain.OrderStatusProcessing,
+	domain.OrderStatusCompleted,
+	domain.OrderStatusFailed,
+	domain.OrderStatusExpired,
+	domain.OrderStatusCancelled,
+	domain.OrderStatusRefunding,
+	domain.OrderStatusRefunded,
+}
+
+func TestOrderTransitions(t *testing.T) {
+	allowed := map[domain.OrderStatus][]domain.OrderStatus{
+		domain.OrderStatusPending:    {domain.OrderStatusPaid, domain.OrderStatusExpired, domain.OrderStatusCancelled},
+		domain.OrderStatusPaid:       {domain.OrderStatusProcessing, domain.OrderStatusFailed, domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+		domain.OrderStatusProcessing: {domain.OrderStatusCompleted, domain.OrderStatusFailed, domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+		domain.OrderStatusCompleted:  {domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+		domain.OrderStatusFailed:     {domain.OrderStatusPaid, domain.OrderStatusProcessing, domain.OrderStatusRefunding, domain.OrderStatusRefunded},
+		domain.OrderStatusRefunding:  {domain.OrderStatusRefunded},
+	}
+
+	// Every pair is checked, so a transition added by mistake fails as well
+	for _, from := range allOrderStatuses {
+		for _, to := range allOrderStatuses {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+
+			err := ValidateTransition(from, to)
+			if want && err != nil {
+				t.Errorf("ValidateTransition(%s, %s): unexpected error %v", from, to, err)
+			}
+			if !want && !errors.Is(err, domain.ErrInvalidOrderTransition) {
+				t.Errorf("ValidateTransition(%s, %s): expected ErrInvalidOrderTransition, got %v", from, to, err)
+			}
+		}
+	}
+}
+
+func TestTerminalStatusesHaveNoTransitions(t *testing.T) {
+	for _, status := range []domain.OrderStatus{domain.OrderStatusExpired, domain.OrderStatusCancelled, domain.OrderStatusRefunded} {
+		if next := orderTransitions[status]; len(next) != 0 {
+			t.Errorf("%s is terminal but may move to %v", status, next)
+		}
+	}
+}
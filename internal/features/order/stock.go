@@ -0,0 +1,172 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/cache"
+	"go-war-ticket-service/internal/utils"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// stockCacheTTL is how long a tier's stock stays cached after hydration
+	stockCacheTTL = time.Hour
+	// stockReservationGrace keeps a reservation around after its order's
+	// window closes, so the sweeper still finds it when releasing the order
+	stockReservationGrace = time.Hour
+)
+
+// reserveStockScript takes ARGV[1] seats from the tier stock in KEYS[1] and
+// records them in the reservation hash KEYS[2], all in one step. A missing
+// stock key is hydrated with the DB value in ARGV[2] first, so concurrent
+// cache misses can't overwrite seats another request already took. Returns 1
+// when reserved and 0 when there isn't enough stock.
+var reserveStockScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	return -1
+end
+local stock = redis.call("GET", KEYS[1])
+if not stock then
+	stock = ARGV[2]
+	redis.call("SET", KEYS[1], stock, "EX", ARGV[3])
+end
+if tonumber(stock) < tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("DECRBY", KEYS[1], ARGV[1])
+redis.call("HSET", KEYS[2], "stock_key", KEYS[1], "qty", ARGV[1], "released", 0)
+redis.call("EXPIRE", KEYS[2], ARGV[4])
+return 1
+`)
+
+// releaseReservationScript gives the seats recorded in the reservation hash
+// KEYS[1] back to the stock in KEYS[2], at most once. Returns 1 when released,
+// 0 when it already was and -1 when there is no reservation to go by.
+var releaseReservationScript = redis.NewScript(`
+local qty = redis.call("HGET", KEYS[1], "qty")
+if not qty then
+	return -1
+end
+if redis.call("HGET", KEYS[1], "released") == "1" then
+	return 0
+end
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	redis.call("INCRBY", KEYS[2], qty)
+end
+redis.call("HSET", KEYS[1], "released", 1)
+return 1
+`)
+
+// purchaseCountTTL bounds how long a per-user counter lives without activity.
+// It is rebuilt from the DB when missing, so expiry is always safe.
+const purchaseCountTTL = 24 * time.Hour
+
+const (
+	quotaMissing  = -1
+	quotaRejected = 0
+	quotaReserved = 1
+)
+
+// reservePurchaseScript adds ARGV[1] tickets to a user's counter unless that
+// would go over the limit in ARGV[2]. A missing counter returns -1 so the
+// caller can hydrate it from the DB and try again.
+var reservePurchaseScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return -1
+end
+if tonumber(current) + tonumber(ARGV[1]) > tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("INCRBY", KEYS[1], ARGV[1])
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+return 1
+`)
+
+func stockKey(eventID, tierID uuid.UUID) string {
+	return fmt.Sprintf(utils.EventTierStockKey, eventID.String(), tierID.String())
+}
+
+func stockReservationKey(bookingID string) string {
+	return fmt.Sprintf(utils.StockReservationKey, bookingID)
+}
+
+// reserveStockInRedis takes qty seats from the tier for the order bookingID
+func reserveStockInRedis(ctx context.Context, client *redis.Client, tier *domain.TicketTier, bookingID string, qty int, ttl time.Duration) error {
+	keys := []string{stockKey(tier.EventID, tier.ID), stockReservationKey(bookingID)}
+	args := []interface{}{
+		qty,
+		tier.AvailableStock,
+		int(stockCacheTTL.Seconds()),
+		int((ttl + stockReservationGrace).Seconds()),
+	}
+
+	reserved, err := reserveStockScript.Run(ctx, client, keys, args...).Int()
+	if err != nil {
+		return fmt.Errorf("failed to reserve redis stock: %w", err)
+	}
+
+	switch reserved {
+	case 1:
+	case -1:
+		return errBookingIDTaken
+	default:
+		return domain.ErrNotEnoughStock
+	}
+
+	return nil
+}
+
+// releaseReservationInRedis returns the seats an order reserved. Orders whose
+// reservation has already expired are released by quantity instead.
+func releaseReservationInRedis(ctx context.Context, client *redis.Client, o domain.Order) error {
+	keys := []string{stockReservationKey(o.BookingID), stockKey(o.EventID, o.TierID)}
+
+	released, err := releaseReservationScript.Run(ctx, client, keys).Int()
+	if err != nil {
+		return fmt.Errorf("failed to release redis reservation: %w", err)
+	}
+
+	if released == -1 {
+		return releaseStockInRedis(ctx, client, o.EventID, o.TierID, o.Quantity)
+	}
+
+	return nil
+}
+
+func purchaseCountKey(eventID, userID uuid.UUID) string {
+	return fmt.Sprintf(utils.UserPurchaseCountKey, eventID.String(), userID.String())
+}
+
+// releaseStockInRedis returns qty seats to the cached stock of a ticket tier
+func releaseStockInRedis(ctx context.Context, client *redis.Client, eventID, tierID uuid.UUID, qty int) error {
+	if err := cache.IncrByIfExists(ctx, client, stockKey(eventID, tierID), qty); err != nil {
+		return fmt.Errorf("failed to release redis stock: %w", err)
+	}
+
+	return nil
+}
+
+// releasePurchaseQuota gives qty tickets back to the user's per event limit
+func releasePurchaseQuota(ctx context.Context, client *redis.Client, eventID, userID uuid.UUID, qty int) error {
+	if err := cache.IncrByIfExists(ctx, client, purchaseCountKey(eventID, userID), -qty); err != nil {
+		return fmt.Errorf("failed to release purchase quota: %w", err)
+	}
+
+	return nil
+}
+
+// releaseOrderInRedis undoes everything an order reserved in the cache. It
+// runs after ReleaseOrder commits, a failure leaves the cache short of seats
+// until the StockReconciler corrects it.
+func releaseOrderInRedis(ctx context.Context, client *redis.Client, o domain.Order) error {
+	if err := releaseReservationInRedis(ctx, client, o); err != nil {
+		return err
+	}
+
+	return releasePurchaseQuota(ctx, client, o.EventID, o.UserID, o.Quantity)
+}
@@ -0,0 +1,218 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	concurrentBuyers = 2000
+	testReservation  = 15 * time.Minute
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{
+		Addr:     server.Addr(),
+		PoolSize: 64,
+	})
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
+
+// countingRepo answers the quota hydration lookup, every other Repository
+// method panics through the nil embedded interface
+type countingRepo struct {
+	Repository
+	held  int
+	calls atomic.Int32
+}
+
+func (r *countingRepo) CountUserTickets(ctx context.Context, userID, eventID uuid.UUID) (int, error) {
+	r.calls.Add(1)
+	return r.held, nil
+}
+
+func TestReserveStockNeverOversells(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+
+	const stock = 100
+	tier := &domain.TicketTier{
+		BaseModel:      domain.BaseModel{ID: uuid.New()},
+		EventID:        uuid.New(),
+		AvailableStock: stock,
+	}
+
+	var (
+		wg       sync.WaitGroup
+		reserved atomic.Int64
+		rejected atomic.Int64
+		failed   atomic.Int64
+	)
+
+	// Buyers take 1 to 3 seats each, far more than the tier holds
+	for i := 0; i < concurrentBuyers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			qty := i%3 + 1
+			err := reserveStockInRedis(ctx, client, tier, fmt.Sprintf("WT-TEST-%d", i), qty, testReservation)
+			switch {
+			case err == nil:
+				reserved.Add(int64(qty))
+			case errors.Is(err, domain.ErrNotEnoughStock):
+				rejected.Add(1)
+			default:
+				failed.Add(1)
+				t.Errorf("reserve %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if failed.Load() > 0 {
+		t.Fatalf("%d reservations failed unexpectedly", failed.Load())
+	}
+	if reserved.Load() > stock {
+		t.Fatalf("oversold: reserved %d seats out of %d", reserved.Load(), stock)
+	}
+	if rejected.Load() == 0 {
+		t.Fatalf("expected some buyers to be turned away")
+	}
+
+	left, err := client.Get(ctx, stockKey(tier.EventID, tier.ID)).Int64()
+	if err != nil {
+		t.Fatalf("read stock: %v", err)
+	}
+	if left < 0 {
+		t.Fatalf("stock went negative: %d", left)
+	}
+	if left+reserved.Load() != stock {
+		t.Fatalf("stock %d + reserved %d does not add up to %d", left, reserved.Load(), stock)
+	}
+}
+
+func TestReleaseReservationIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	client := newTestRedis(t)
+
+	const stock = 50
+	tier := &domain.TicketTier{
+		BaseModel:      domain.BaseModel{ID: uuid.New()},
+		EventID:        uuid.New(),
+		AvailableStock: stock,
+	}
+
+	var orders []domain.Order
+	for i := 0; i < stock; i++ {
+		bookingID := fmt.Sprintf("WT-TEST-%d", i)
+		if err := reserveStockInRedis(ctx, client, tier, bookingID, 1, testReservation); err != nil {
+			t.Fatalf("reserve %d: %v", i, err)
+		}
+		orders = append(orders, domain.Order{BookingID: bookingID, EventID: tier.EventID, TierID: tier.ID, Quantity: 1})
+	}
+
+	if err := reserveStockInRedis(ctx, client, tier, "WT-TEST-EXTRA", 1, testReservation); !errors.Is(err, domain.ErrNotEnoughStock) {
+		t.Fatalf("expected ErrNotEnoughStock once sold out, got %v", err)
+	}
+
+	// Every order is released several times at once, as the sweeper, a
+	// cancellation and a webhook racing each other would
+	var wg sync.WaitGroup
+	for _, o := range orders {
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(o domain.Order) {
+				defer wg.Done()
+				if err := releaseReservationInRedis(ctx, client, o); err != nil {
+					t.Errorf("release %s: %v", o.BookingID, err)
+				}
+			}(o)
+		}
+	}
+	wg.Wait()
+
+	left, err := client.Get(ctx, stockKey(tier.EventID, tier.ID)).Int64()
+	if err != nil {
+		t.Fatalf("read stock: %v", err)
+	}
+	if left != stock {
+		t.Fatalf("expected stock back to %d, got %d", stock, left)
+	}
+}
+
+func TestReservePurchaseQuotaEnforcesLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		held  int // tickets the user already holds in the database
+		limit int
+	}{
+		{name: "fresh user", held: 0, limit: 4},
+		{name: "hydrated from database", held: 3, limit: 6},
+		{name: "already at limit", held: 2, limit: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := &countingRepo{held: tt.held}
+			u := &usecase{
+				repo:  repo,
+				cache: newTestRedis(t),
+				log:   zap.NewNop().Sugar(),
+			}
+
+			eventID, userID := uuid.New(), uuid.New()
+
+			var (
+				wg       sync.WaitGroup
+				reserved atomic.Int64
+			)
+			for i := 0; i < concurrentBuyers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					err := u.reservePurchaseQuota(ctx, eventID, userID, 1, tt.limit)
+					switch {
+					case err == nil:
+						reserved.Add(1)
+					case errors.Is(err, domain.ErrPurchaseLimitExceeded):
+					default:
+						t.Errorf("reserve quota: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if want := int64(tt.limit - tt.held); reserved.Load() != want {
+				t.Fatalf("expected %d tickets within the limit, got %d", want, reserved.Load())
+			}
+			if repo.calls.Load() == 0 {
+				t.Fatalf("expected the quota to be hydrated from the database")
+			}
+
+			// Rolled back seats become available again
+			u.rollbackPurchaseQuota(ctx, eventID, userID, 1, tt.limit)
+			if err := u.reservePurchaseQuota(ctx, eventID, userID, 1, tt.limit); tt.limit > tt.held && err != nil {
+				t.Fatalf("expected a released seat to be reservable again: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/domain"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReservationTTL      = 15 * time.Minute
+	defaultExpirySweepInterval = time.Minute
+	expirySweepBatchSize       = 100
+)
+
+// ExpirySweeper releases the seats held by PENDING orders whose reservation
+// window has passed without a payment
+type ExpirySweeper struct {
+	repo  Repository
+	svc   Service
+	cache *redis.Client
+	cfg   configs.Config
+	log   *zap.SugaredLogger
+}
+
+func NewExpirySweeper(
+	repo Repository,
+	svc Service,
+	cache *redis.Client,
+	cfg configs.Config,
+	log *zap.SugaredLogger,
+) *ExpirySweeper {
+	return &ExpirySweeper{
+		repo:  repo,
+		svc:   svc,
+		cache: cache,
+		cfg:   cfg,
+		log:   log.Named("OrderExpirySweeper"),
+	}
+}
+
+// Start sweeps expired orders every interval until ctx is cancelled
+func (s *ExpirySweeper) Start(ctx context.Context) {
+	interval := s.cfg.OrderExpirySweepInterval
+	if interval <= 0 {
+		interval = defaultExpirySweepInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ExpirySweeper) sweep(ctx context.Context) {
+	orders, err := s.repo.GetExpiredPendingOrders(ctx, time.Now(), expirySweepBatchSize)
+	if err != nil {
+		s.log.Errorf("failed to get expired orders: %v", err)
+		return
+	}
+
+	for _, order := range orders {
+		// Don't release seats that were paid for but whose webhook got lost
+		if err := s.svc.SyncPaymentStatus(ctx, order.BookingID); err != nil {
+			s.log.Warnf("failed to sync payment status for order %s: %v", order.BookingID, err)
+		}
+
+		change := StatusChange{Actor: ActorExpirySweeper, Reason: "reservation window elapsed without payment"}
+		expired, err := s.repo.ReleaseOrder(ctx, order.BookingID, domain.OrderStatusExpired, change)
+		if err != nil {
+			// Paid, cancelled or expired by someone else in the meantime
+			if !errors.Is(err, domain.ErrInvalidOrderTransition) {
+				s.log.Errorf("failed to expire order %s: %v", order.BookingID, err)
+			}
+			continue
+		}
+
+		if err := releaseOrderInRedis(ctx, s.cache, *expired); err != nil {
+			s.log.Warnf("failed to release cached seats of order %s: %v", order.BookingID, err)
+		}
+
+		s.log.Infof("Order %s expired, released %d seats", order.BookingID, order.Quantity)
+	}
+}
+
+// reservationTTL returns how long a new order may stay PENDING
+func reservationTTL(cfg configs.Config) time.Duration {
+	if cfg.OrderReservationTTL <= 0 {
+		return defaultReservationTTL
+	}
+	return cfg.OrderReservationTTL
+}
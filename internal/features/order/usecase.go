@@ -2,11 +2,13 @@ package order
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/idgen"
+	"go-war-ticket-service/internal/platform/payment"
 	"go-war-ticket-service/internal/platform/storage"
-	"go-war-ticket-service/internal/utils"
 	"go-war-ticket-service/internal/utils/contextutil"
 	"strings"
 	"time"
@@ -17,12 +19,16 @@ import (
 	"go.uber.org/zap"
 )
 
+const defaultOrderCurrency = "IDR"
+
 type usecase struct {
 	repo        Repository
 	log         *zap.SugaredLogger
 	minioClient *minio.Client
 	cfg         configs.Config
 	cache       *redis.Client
+	provider    PaymentProvider
+	ids         *idgen.Generator
 }
 
 func NewUsecase(
@@ -31,6 +37,8 @@ func NewUsecase(
 	minioClient *minio.Client,
 	cfg configs.Config,
 	cache *redis.Client,
+	provider PaymentProvider,
+	ids *idgen.Generator,
 ) Usecase {
 	return &usecase{
 		repo:        r,
@@ -38,30 +46,83 @@ func NewUsecase(
 		minioClient: minioClient,
 		cfg:         cfg,
 		cache:       cache,
+		provider:    provider,
+		ids:         ids,
 	}
 }
 
 func (u *usecase) CreateOrder(ctx context.Context, order domain.Order) (*domain.Order, error) {
-	if err := u.decreaseStockInRedis(ctx, order.EventID, order.Quantity); err != nil {
-		return nil, err
+	currentUserID, _ := contextutil.GetUserID(ctx)
+
+	// Tickets are delivered by email, so the address has to be confirmed first
+	user, err := u.repo.GetUserByID(ctx, currentUserID)
+	if err != nil {
+		u.log.Errorf("failed to get user: %v", err)
+		return nil, domain.ErrInternal
 	}
 
-	currentUserID, _ := contextutil.GetUserID(ctx)
-	takenUserID := strings.Split(currentUserID.String(), "-")[0]
-	bookingID := fmt.Sprintf("WT-%s-%s", takenUserID, utils.GenerateRandomString(6))
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if !user.IsEmailVerified() {
+		return nil, domain.ErrEmailNotVerified
+	}
+
+	// The waiting room admits users to one event at a time
+	if admittedEventID, ok := contextutil.GetAdmittedEventID(ctx); ok && admittedEventID != order.EventID {
+		return nil, domain.ErrAdmissionRequired
+	}
+
+	tier, err := u.repo.GetTierByID(ctx, order.TierID)
+	if err != nil {
+		u.log.Errorf("failed to get ticket tier: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	if tier == nil || tier.EventID != order.EventID {
+		return nil, domain.ErrTierNotFound
+	}
+
+	if !tier.IsOnSale(time.Now()) {
+		return nil, domain.ErrTierNotOnSale
+	}
+
+	if tier.MaxPerOrder > 0 && order.Quantity > tier.MaxPerOrder {
+		return nil, domain.ErrTierLimitExceeded
+	}
+
+	event, err := u.repo.GetEventByID(ctx, tier.EventID)
+	if err != nil {
+		u.log.Errorf("failed to get event: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	limit := u.purchaseLimit(event)
+	if limit > 0 {
+		if err := u.reservePurchaseQuota(ctx, tier.EventID, currentUserID, order.Quantity, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	expiresAt := time.Now().Add(reservationTTL(u.cfg))
 
 	newOrder := domain.Order{
-		BookingID: strings.ToUpper(bookingID),
 		UserID:    currentUserID,
 		EventID:   order.EventID,
+		TierID:    tier.ID,
 		Quantity:  order.Quantity,
 		Status:    domain.OrderStatusPending,
+		ExpiresAt: &expiresAt,
+	}
+	u.priceOrder(&newOrder, tier)
+
+	if err := u.placeOrder(ctx, &newOrder, tier, limit); err != nil {
+		u.rollbackPurchaseQuota(ctx, tier.EventID, currentUserID, order.Quantity, limit)
+		return nil, err
 	}
 
-	// Create new order in DB
-	if err := u.repo.CreateOrder(ctx, &newOrder); err != nil {
-		u.log.Errorf("failed to create order: %v", err)
-		u.rollbackStock(ctx, order.EventID, order.Quantity)
+	if err := u.createCharge(ctx, &newOrder); err != nil {
 		return nil, err
 	}
 
@@ -79,12 +140,55 @@ func (u *usecase) CreateOrder(ctx context.Context, order domain.Order) (*domain.
 	return &newOrder, nil
 }
 
+// placeOrder reserves the stock and stores the order under a fresh booking
+// ID, drawing a new one if the ID turns out to be taken
+func (u *usecase) placeOrder(ctx context.Context, newOrder *domain.Order, tier *domain.TicketTier, limit int) error {
+	for attempt := 1; ; attempt++ {
+		bookingID, err := newBookingID(u.ids)
+		if err != nil {
+			u.log.Errorf("failed to generate booking id: %v", err)
+			return domain.ErrInternal
+		}
+		newOrder.BookingID = bookingID
+
+		err = reserveStockInRedis(ctx, u.cache, tier, newOrder.BookingID, newOrder.Quantity, reservationTTL(u.cfg))
+		if err == nil {
+			// Create new order in DB
+			err = u.repo.CreateOrder(ctx, newOrder, limit)
+			if err != nil {
+				u.rollbackStock(ctx, *newOrder)
+			}
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		if errors.Is(err, errBookingIDTaken) && attempt < maxBookingIDAttempts {
+			u.log.Warnf("booking id %s already taken, drawing another", bookingID)
+			continue
+		}
+
+		if !errors.Is(err, domain.ErrNotEnoughStock) && !errors.Is(err, domain.ErrPurchaseLimitExceeded) {
+			u.log.Errorf("failed to place order: %v", err)
+		}
+		if errors.Is(err, errBookingIDTaken) {
+			return domain.ErrInternal
+		}
+		return err
+	}
+}
+
 func (u *usecase) GetOrderByBookingID(ctx context.Context, bookingID string) (*domain.Order, error) {
 	order, err := u.repo.GetOrderByBookingID(ctx, bookingID)
 	if err != nil {
 		return nil, err
 	}
 
+	if order.ID == uuid.Nil {
+		return nil, domain.ErrOrderNotFound
+	}
+
 	// presigned url
 	presignedUrl, _ := storage.GetPresignedObject(
 		u.minioClient,
@@ -140,52 +244,176 @@ func (u *usecase) GetOrderList(ctx context.Context) ([]domain.Order, error) {
 	return result, nil
 }
 
-// Decrease stock in Redis & Handle Cache Miss
-func (u *usecase) decreaseStockInRedis(ctx context.Context, eventID uuid.UUID, qty int) error {
-	redisKey := fmt.Sprintf(utils.EventStockKey, eventID.String())
+func (u *usecase) GetOrderStatusHistory(ctx context.Context, bookingID string) ([]domain.OrderStatusHistory, error) {
+	order, err := u.repo.GetOrderByBookingID(ctx, bookingID)
+	if err != nil {
+		u.log.Errorf("failed to get order: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	// Customers only see their own orders, staff can audit any of them
+	currentUserID, _ := contextutil.GetUserID(ctx)
+	role, _ := contextutil.GetUserRole(ctx)
+	if order.ID == uuid.Nil || (order.UserID != currentUserID && role != domain.RoleAdmin) {
+		return nil, domain.ErrOrderNotFound
+	}
 
-	// Check if key exists
-	exists, err := u.cache.Exists(ctx, redisKey).Result()
+	history, err := u.repo.GetOrderStatusHistory(ctx, order.ID)
 	if err != nil {
-		return fmt.Errorf("redis error: %w", err)
+		u.log.Errorf("failed to get order status history: %v", err)
+		return nil, domain.ErrInternal
 	}
 
-	// CACHE MISS: If key doesn't exist, load from DB and set to Redis
-	if exists == 0 {
-		u.log.Info("Cache miss detected, loading from DB...")
-		event, err := u.repo.GetEventByID(ctx, eventID)
-		if err != nil {
-			return err
-		}
-		if event == nil {
-			return domain.ErrEventNotFound
+	return history, nil
+}
+
+// CancelOrder lets a customer give up a PENDING order and its reserved seats
+func (u *usecase) CancelOrder(ctx context.Context, bookingID string) (*domain.Order, error) {
+	currentUserID, _ := contextutil.GetUserID(ctx)
+
+	order, err := u.repo.GetOrderByBookingID(ctx, bookingID)
+	if err != nil {
+		u.log.Errorf("failed to get order: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	// Someone else's order is reported as missing so booking IDs can't be probed
+	if order.ID == uuid.Nil || order.UserID != currentUserID {
+		return nil, domain.ErrOrderNotFound
+	}
+
+	change := StatusChange{Actor: UserActor(currentUserID), Reason: "cancelled by customer"}
+	cancelled, err := u.repo.ReleaseOrder(ctx, bookingID, domain.OrderStatusCancelled, change)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidOrderTransition) {
+			return nil, err
 		}
+		u.log.Errorf("failed to cancel order %s: %v", bookingID, err)
+		return nil, domain.ErrInternal
+	}
+
+	if err := releaseOrderInRedis(ctx, u.cache, *cancelled); err != nil {
+		u.log.Warnf("failed to release cached seats of order %s: %v", bookingID, err)
+	}
+
+	cancelled.Event = order.Event
+	cancelled.Tier = order.Tier
+	return cancelled, nil
+}
+
+// priceOrder snapshots the tier price and fees onto the order so its amounts
+// stay fixed even if the organiser changes prices later
+func (u *usecase) priceOrder(order *domain.Order, tier *domain.TicketTier) {
+	order.UnitPrice = tier.Price
+	order.Subtotal = tier.Price.Mul(order.Quantity)
+	order.Fee = domain.NewMoneyFromFloat(u.cfg.OrderFeePerTicket).Mul(order.Quantity)
+	order.Discount = 0
+	order.TotalPrice = order.Subtotal + order.Fee - order.Discount
+	order.Currency = orderCurrency(u.cfg)
+}
+
+func orderCurrency(cfg configs.Config) string {
+	if cfg.OrderCurrency == "" {
+		return defaultOrderCurrency
+	}
+	return strings.ToUpper(cfg.OrderCurrency)
+}
+
+// createCharge opens a payment with the provider. If that fails the order can
+// never be paid, so its reservation is released straight away.
+func (u *usecase) createCharge(ctx context.Context, order *domain.Order) error {
+	charge, err := u.provider.CreateCharge(ctx, payment.ChargeRequest{
+		OrderID:   order.BookingID,
+		Amount:    order.TotalPrice,
+		Currency:  order.Currency,
+		UnitPrice: order.UnitPrice,
+		Quantity:  order.Quantity,
+		Fee:       order.Fee,
+		Discount:  order.Discount,
+		ItemName:  fmt.Sprintf("%s - %s", order.Event.Name, order.Tier.Name),
+		ExpiresIn: reservationTTL(u.cfg),
+	})
+	if err != nil {
+		u.log.Errorf("failed to create %s charge for order %s: %v", u.provider.Name(), order.BookingID, err)
 
-		// SetNX (Set if Not Exists) to avoid race condition during re-hydrate
-		// Set stock based on what's in the DB
-		updated := u.cache.SetNX(ctx, redisKey, event.AvailableStock, 1*time.Hour).Val()
-		if !updated {
-			u.log.Warn("Race condition on cache re-hydration")
+		change := StatusChange{Actor: ActorPaymentProvider, Reason: "payment charge could not be created"}
+		expired, expireErr := u.repo.ReleaseOrder(ctx, order.BookingID, domain.OrderStatusExpired, change)
+		if expireErr != nil {
+			u.log.Errorf("failed to release order %s: %v", order.BookingID, expireErr)
+		} else if err := releaseOrderInRedis(ctx, u.cache, *expired); err != nil {
+			u.log.Warnf("failed to release cached seats of order %s: %v", order.BookingID, err)
 		}
+
+		return domain.ErrPaymentUnavailable
+	}
+
+	if err := u.repo.UpdatePaymentDetails(ctx, order.ID, u.provider.Name(), *charge); err != nil {
+		u.log.Errorf("failed to save payment details for order %s: %v", order.BookingID, err)
+		return domain.ErrInternal
 	}
 
-	// EXECUTE DECREMENT (Atomic Operation)
-	remainingStock, err := u.cache.DecrBy(ctx, redisKey, int64(qty)).Result()
+	order.PaymentProvider = u.provider.Name()
+	order.PaymentReference = charge.Reference
+	order.PaymentURL = charge.RedirectURL
+
+	return nil
+}
+
+// purchaseLimit is how many tickets one user may hold for the event, 0 means
+// no limit
+func (u *usecase) purchaseLimit(event *domain.Event) int {
+	if event.MaxTicketsPerUser > 0 {
+		return event.MaxTicketsPerUser
+	}
+	return max(u.cfg.OrderMaxTicketsPerUser, 0)
+}
+
+// reservePurchaseQuota counts qty tickets against the user's limit for the
+// event in one atomic step. The DB check in CreateOrder stays authoritative,
+// this only turns most over-limit attempts away before they touch stock.
+func (u *usecase) reservePurchaseQuota(ctx context.Context, eventID, userID uuid.UUID, qty, limit int) error {
+	redisKey := purchaseCountKey(eventID, userID)
+	args := []interface{}{qty, limit, int(purchaseCountTTL.Seconds())}
+
+	result, err := reservePurchaseScript.Run(ctx, u.cache, []string{redisKey}, args...).Int()
 	if err != nil {
-		return fmt.Errorf("failed to decrement redis: %w", err)
+		return fmt.Errorf("failed to reserve purchase quota: %w", err)
+	}
+
+	if result == quotaMissing {
+		held, err := u.repo.CountUserTickets(ctx, userID, eventID)
+		if err != nil {
+			u.log.Errorf("failed to count user tickets: %v", err)
+			return domain.ErrInternal
+		}
+
+		u.cache.SetNX(ctx, redisKey, held, purchaseCountTTL)
+
+		result, err = reservePurchaseScript.Run(ctx, u.cache, []string{redisKey}, args...).Int()
+		if err != nil {
+			return fmt.Errorf("failed to reserve purchase quota: %w", err)
+		}
 	}
 
-	// Validate stock
-	if remainingStock < 0 {
-		// Stock is empty! Return the number (Increment back)
-		u.cache.IncrBy(ctx, redisKey, int64(qty))
-		return domain.ErrNotEnoughStock
+	if result != quotaReserved {
+		return domain.ErrPurchaseLimitExceeded
 	}
 
 	return nil
 }
 
-func (u *usecase) rollbackStock(ctx context.Context, eventID uuid.UUID, qty int) {
-	redisKey := fmt.Sprintf(utils.EventStockKey, eventID.String())
-	u.cache.IncrBy(ctx, redisKey, int64(qty))
+func (u *usecase) rollbackPurchaseQuota(ctx context.Context, eventID, userID uuid.UUID, qty, limit int) {
+	if limit <= 0 {
+		return
+	}
+
+	if err := releasePurchaseQuota(ctx, u.cache, eventID, userID, qty); err != nil {
+		u.log.Errorf("failed to rollback purchase quota: %v", err)
+	}
+}
+
+func (u *usecase) rollbackStock(ctx context.Context, order domain.Order) {
+	if err := releaseReservationInRedis(ctx, u.cache, order); err != nil {
+		u.log.Errorf("failed to rollback stock: %v", err)
+	}
 }
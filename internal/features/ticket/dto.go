@@ -0,0 +1,27 @@
+package ticket
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CheckInRequest struct {
+	TicketNumber string `json:"ticket_number" validate:"required_without=QRToken"`
+	QRToken      string `json:"qr_token" validate:"required_without=TicketNumber"`
+	GateID       string `json:"gate_id" validate:"required,max=50"`
+}
+
+type CheckInResponse struct {
+	TicketNumber string     `json:"ticket_number"`
+	EventID      uuid.UUID  `json:"event_id"`
+	OrderID      uuid.UUID  `json:"order_id"`
+	Status       string     `json:"status"`
+	UsedAt       *time.Time `json:"used_at"`
+	GateID       string     `json:"gate_id"`
+}
+
+type QRPublicKeyResponse struct {
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
@@ -0,0 +1,68 @@
+package ticket
+
+import (
+	"encoding/base64"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/responses"
+	"go-war-ticket-service/internal/platform/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type Handler struct {
+	usecase   Usecase
+	validator *validator.Validator
+}
+
+func NewHandler(uc Usecase, validator *validator.Validator) *Handler {
+	return &Handler{
+		usecase:   uc,
+		validator: validator,
+	}
+}
+
+func (h *Handler) CheckIn(c *fiber.Ctx) error {
+	var req CheckInRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		errors := h.validator.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	var (
+		ticket *domain.Ticket
+		err    error
+	)
+	if req.QRToken != "" {
+		ticket, err = h.usecase.CheckInWithToken(c.Context(), req.QRToken, req.GateID)
+	} else {
+		ticket, err = h.usecase.CheckIn(c.Context(), req.TicketNumber, req.GateID)
+	}
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	response := CheckInResponse{
+		TicketNumber: ticket.TicketNumber,
+		EventID:      ticket.EventID,
+		OrderID:      ticket.OrderID,
+		Status:       string(ticket.Status),
+		UsedAt:       ticket.UsedAt,
+		GateID:       ticket.UsedByGate,
+	}
+
+	return responses.Success(c, response, "Ticket checked in successfully")
+}
+
+// GetQRPublicKey exposes the key gate scanners cache to verify QR codes offline
+func (h *Handler) GetQRPublicKey(c *fiber.Ctx) error {
+	response := QRPublicKeyResponse{
+		Algorithm: "Ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(h.usecase.QRPublicKey()),
+	}
+
+	return responses.Success(c, response, "success")
+}
@@ -2,9 +2,22 @@ package ticket
 
 import (
 	"context"
+	"crypto/ed25519"
 	"go-war-ticket-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+type Usecase interface {
+	CheckIn(ctx context.Context, ticketNumber string, gateID string) (*domain.Ticket, error)
+	CheckInWithToken(ctx context.Context, token string, gateID string) (*domain.Ticket, error)
+	QRPublicKey() ed25519.PublicKey
+}
+
 type Repository interface {
-	CreateTicket(ctx context.Context, ticket *domain.Ticket) error
+	CreateTicket(ctx context.Context, ticket *domain.Ticket) (bool, error)
+	GetTicketByNumber(ctx context.Context, ticketNumber string) (*domain.Ticket, error)
+	MarkTicketUsed(ctx context.Context, ticketID uuid.UUID, gateID string, usedAt time.Time) (bool, error)
+	CreateTicketScan(ctx context.Context, scan *domain.TicketScan) error
 }
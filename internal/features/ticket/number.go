@@ -0,0 +1,42 @@
+package ticket
+
+import (
+	"errors"
+	"go-war-ticket-service/internal/platform/idgen"
+	"strings"
+)
+
+const (
+	ticketNumberPrefix      = "TIK-"
+	ticketCodeLength        = 12 // random characters, before the check character
+	maxTicketNumberAttempts = 3
+)
+
+// errTicketNumberTaken means a freshly generated ticket number is already
+// used by another ticket, and a new one has to be drawn
+var errTicketNumberTaken = errors.New("ticket number already taken")
+
+// newTicketNumber returns a ticket number such as TIK-4F7QK2M9XBHRW
+func newTicketNumber(ids *idgen.Generator) (string, error) {
+	code, err := ids.Generate(ticketCodeLength)
+	if err != nil {
+		return "", err
+	}
+	return ticketNumberPrefix + code, nil
+}
+
+// normalizeTicketNumber cleans up a ticket number typed in at a gate and
+// checks its check character, so typos are rejected without a lookup.
+// Numbers issued before check characters existed (TIK-<booking>-<digits>)
+// are passed through unchanged.
+func normalizeTicketNumber(ids *idgen.Generator, number string) (string, bool) {
+	number = strings.ToUpper(strings.TrimSpace(number))
+
+	code, ok := strings.CutPrefix(number, ticketNumberPrefix)
+	if !ok || len(code) != ticketCodeLength+1 {
+		return number, true
+	}
+
+	code = ids.Normalize(code)
+	return ticketNumberPrefix + code, ids.Valid(code)
+}
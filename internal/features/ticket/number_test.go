@@ -0,0 +1,47 @@
+package ticket
+
+import (
+	"go-war-ticket-service/internal/platform/idgen"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTicketNumber(t *testing.T) {
+	ids := idgen.NewCrockford()
+
+	number, err := newTicketNumber(ids)
+	if err != nil {
+		t.Fatalf("new ticket number: %v", err)
+	}
+	code := strings.TrimPrefix(number, ticketNumberPrefix)
+
+	// A one character typo in the code, swapping its first character
+	typoChar := "0"
+	if code[0] == '0' {
+		typoChar = "1"
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{name: "as issued", input: number, want: number, wantOK: true},
+		{name: "typed in lower case", input: "  " + strings.ToLower(number) + "\n", want: number, wantOK: true},
+		{name: "typo", input: ticketNumberPrefix + typoChar + code[1:], wantOK: false},
+		{name: "legacy number", input: "tik-wt-abc123-1", want: "TIK-WT-ABC123-1", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := normalizeTicketNumber(ids, tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("normalizeTicketNumber(%q) ok = %v, want %v", tt.input, ok, tt.wantOK)
+			}
+			if tt.wantOK && got != tt.want {
+				t.Fatalf("normalizeTicketNumber(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
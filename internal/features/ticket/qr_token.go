@@ -0,0 +1,113 @@
+package ticket
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// qrTokenPrefix versions the token format so scanners can reject codes they don't understand
+const qrTokenPrefix = "TB1"
+
+// QRTokenGracePeriod is how long after the event starts a ticket QR stays valid
+const QRTokenGracePeriod = 24 * time.Hour
+
+// QRClaims is the payload carried inside a ticket QR code
+type QRClaims struct {
+	TicketID     uuid.UUID `json:"tid"`
+	EventID      uuid.UUID `json:"eid"`
+	TicketNumber string    `json:"tn"`
+	ExpiresAt    int64     `json:"exp"`
+}
+
+// QRSigner signs ticket QR payloads with an Ed25519 key. Scanners only need
+// the public key to verify a code, so verification works without connectivity.
+type QRSigner struct {
+	privateKey ed25519.PrivateKey
+}
+
+func NewQRSigner(privateKey ed25519.PrivateKey) *QRSigner {
+	return &QRSigner{privateKey: privateKey}
+}
+
+// ParseQRPrivateKey decodes a base64 encoded 32-byte Ed25519 seed
+func ParseQRPrivateKey(encoded string) (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode QR private key: %w", err)
+	}
+
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("QR private key must be %d bytes, got %d", ed25519.SeedSize, len(seed))
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func (s *QRSigner) PublicKey() ed25519.PublicKey {
+	return s.privateKey.Public().(ed25519.PublicKey)
+}
+
+// Sign produces a compact token: TB1.<base64url(claims)>.<base64url(signature)>
+func (s *QRSigner) Sign(claims QRClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal QR claims: %w", err)
+	}
+
+	signingInput := qrTokenPrefix + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(s.privateKey, []byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyQRToken checks the signature and expiry of a scanned QR token using
+// only the public key, and returns its claims
+func VerifyQRToken(token string, publicKey ed25519.PublicKey, now time.Time) (*QRClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != qrTokenPrefix {
+		return nil, domain.ErrInvalidTicketToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, domain.ErrInvalidTicketToken
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if !ed25519.Verify(publicKey, []byte(signingInput), signature) {
+		return nil, domain.ErrInvalidTicketToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, domain.ErrInvalidTicketToken
+	}
+
+	var claims QRClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, domain.ErrInvalidTicketToken
+	}
+
+	if now.Unix() > claims.ExpiresAt {
+		return nil, domain.ErrTicketTokenExpired
+	}
+
+	return &claims, nil
+}
+
+// GenerateQRPrivateKey creates a random Ed25519 key, used when no key is
+// configured in development. Codes signed with it stop verifying on restart.
+func GenerateQRPrivateKey() (ed25519.PrivateKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR private key: %w", err)
+	}
+	return privateKey, nil
+}
@@ -0,0 +1,121 @@
+package ticket
+
+import (
+	"encoding/base64"
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTestSigner(t *testing.T) *QRSigner {
+	t.Helper()
+
+	privateKey, err := GenerateQRPrivateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	return NewQRSigner(privateKey)
+}
+
+func TestQRTokenRoundTrip(t *testing.T) {
+	signer := newTestSigner(t)
+	now := time.Now()
+
+	claims := QRClaims{
+		TicketID:     uuid.New(),
+		EventID:      uuid.New(),
+		TicketNumber: "TIK-4F7QK2M9XBHRW",
+		ExpiresAt:    now.Add(time.Hour).Unix(),
+	}
+
+	token, err := signer.Sign(claims)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if !strings.HasPrefix(token, qrTokenPrefix+".") {
+		t.Fatalf("expected a %s token, got %s", qrTokenPrefix, token)
+	}
+
+	got, err := VerifyQRToken(token, signer.PublicKey(), now)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if *got != claims {
+		t.Fatalf("expected claims %+v, got %+v", claims, *got)
+	}
+}
+
+func TestQRTokenExpiry(t *testing.T) {
+	signer := newTestSigner(t)
+	expiresAt := time.Now().Add(time.Hour)
+
+	token, err := signer.Sign(QRClaims{TicketID: uuid.New(), EventID: uuid.New(), ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	if _, err := VerifyQRToken(token, signer.PublicKey(), expiresAt); err != nil {
+		t.Fatalf("expected the token to be valid up to its expiry, got %v", err)
+	}
+	if _, err := VerifyQRToken(token, signer.PublicKey(), expiresAt.Add(time.Second)); !errors.Is(err, domain.ErrTicketTokenExpired) {
+		t.Fatalf("expected ErrTicketTokenExpired, got %v", err)
+	}
+}
+
+func TestQRTokenRejectsForgeries(t *testing.T) {
+	signer := newTestSigner(t)
+	token, err := signer.Sign(QRClaims{TicketID: uuid.New(), EventID: uuid.New(), TicketNumber: "TIK-A", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	parts := strings.Split(token, ".")
+
+	// Claims swapped for another ticket's, keeping the original signature
+	forgedClaims := base64.RawURLEncoding.EncodeToString([]byte(`{"tid":"` + uuid.NewString() + `","tn":"TIK-B","exp":9999999999}`))
+
+	otherToken, err := newTestSigner(t).Sign(QRClaims{TicketNumber: "TIK-A", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	tests := map[string]string{
+		"other key":        otherToken,
+		"tampered claims":  parts[0] + "." + forgedClaims + "." + parts[2],
+		"unknown version":  "TB2." + parts[1] + "." + parts[2],
+		"missing part":     parts[0] + "." + parts[1],
+		"garbage":          "not-a-token",
+		"broken signature": parts[0] + "." + parts[1] + ".!!!",
+	}
+
+	for name, token := range tests {
+		if _, err := VerifyQRToken(token, signer.PublicKey(), time.Now()); !errors.Is(err, domain.ErrInvalidTicketToken) {
+			t.Errorf("%s: expected ErrInvalidTicketToken, got %v", name, err)
+		}
+	}
+}
+
+func TestParseQRPrivateKey(t *testing.T) {
+	seed := make([]byte, 32)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+
+	key, err := ParseQRPrivateKey(" " + base64.StdEncoding.EncodeToString(seed) + "\n")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if string(key.Seed()) != string(seed) {
+		t.Fatalf("parsed key does not match its seed")
+	}
+
+	if _, err := ParseQRPrivateKey(base64.StdEncoding.EncodeToString(seed[:16])); err == nil {
+		t.Fatalf("expected a short seed to be rejected")
+	}
+	if _, err := ParseQRPrivateKey("not base64"); err == nil {
+		t.Fatalf("expected invalid base64 to be rejected")
+	}
+}
@@ -2,11 +2,19 @@ package ticket
 
 import (
 	"context"
+	"errors"
 	"go-war-ticket-service/internal/domain"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// errOrderNotProcessing means the order left PROCESSING, refunded for
+// instance, while its tickets were being generated
+var errOrderNotProcessing = errors.New("order is no longer processing")
+
 type repository struct {
 	db *gorm.DB
 }
@@ -15,6 +23,83 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) CreateTicket(ctx context.Context, ticket *domain.Ticket) error {
-	return r.db.Create(&ticket).Error
-}
\ No newline at end of file
+// CreateTicket inserts a ticket unless its order already has one in the same
+// slot, and reports whether this call created it. A redelivered generation
+// job can therefore never issue a second ticket for a slot. A clash on the
+// ticket number returns errTicketNumberTaken.
+//
+// The order row is share locked first and must still be PROCESSING. Refunds
+// update it under an exclusive lock, so a ticket is either inserted before
+// a refund revokes it or not inserted at all.
+func (r *repository) CreateTicket(ctx context.Context, ticket *domain.Ticket) (bool, error) {
+	var created bool
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order domain.Order
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+			Select("id", "status").
+			Where("id = ?", ticket.OrderID).
+			First(&order).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return domain.ErrOrderNotFound
+			}
+			return err
+		}
+
+		if order.Status != domain.OrderStatusProcessing {
+			return errOrderNotProcessing
+		}
+
+		result := tx.Clauses(clause.OnConflict{
+			Columns:     []clause.Column{{Name: "order_id"}, {Name: "seq"}},
+			TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "seq > 0"}}},
+			DoNothing:   true,
+		}).Create(ticket)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+				return errTicketNumberTaken
+			}
+			return result.Error
+		}
+
+		created = result.RowsAffected == 1
+		return nil
+	})
+
+	return created, err
+}
+
+func (r *repository) GetTicketByNumber(ctx context.Context, ticketNumber string) (*domain.Ticket, error) {
+	var ticket domain.Ticket
+	err := r.db.WithContext(ctx).Where("ticket_number = ?", ticketNumber).First(&ticket).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// MarkTicketUsed flips a VALID ticket of a COMPLETED order to USED in a
+// single conditional update, so only one of several concurrent scans can win
+// and tickets of an order being refunded are never let in. It reports
+// whether this call performed the flip.
+func (r *repository) MarkTicketUsed(ctx context.Context, ticketID uuid.UUID, gateID string, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&domain.Ticket{}).
+		Where("id = ? AND status = ?", ticketID, domain.TicketStatusValid).
+		Where("EXISTS (SELECT 1 FROM orders WHERE orders.id = tickets.order_id AND orders.status = ?)", domain.OrderStatusCompleted).
+		Updates(map[string]interface{}{
+			"status":       domain.TicketStatusUsed,
+			"used_at":      usedAt,
+			"used_by_gate": gateID,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) CreateTicketScan(ctx context.Context, scan *domain.TicketScan) error {
+	return r.db.WithContext(ctx).Create(scan).Error
+}
@@ -0,0 +1,160 @@
+package ticket
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/idgen"
+	"go-war-ticket-service/internal/utils/contextutil"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type usecase struct {
+	repo        Repository
+	qrPublicKey ed25519.PublicKey
+	ids         *idgen.Generator
+	log         *zap.SugaredLogger
+}
+
+func NewUsecase(r Repository, qrPublicKey ed25519.PublicKey, ids *idgen.Generator, log *zap.SugaredLogger) Usecase {
+	return &usecase{
+		repo:        r,
+		qrPublicKey: qrPublicKey,
+		ids:         ids,
+		log:         log.Named("TicketUsecase"),
+	}
+}
+
+func (u *usecase) QRPublicKey() ed25519.PublicKey {
+	return u.qrPublicKey
+}
+
+func (u *usecase) CheckIn(ctx context.Context, ticketNumber string, gateID string) (*domain.Ticket, error) {
+	ticketNumber, ok := normalizeTicketNumber(u.ids, ticketNumber)
+	if !ok {
+		return nil, domain.ErrTicketNotFound
+	}
+
+	ticket, err := u.getTicket(ctx, ticketNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.redeem(ctx, ticket, gateID)
+}
+
+// CheckInWithToken verifies a scanned QR token before redeeming the ticket it points to
+func (u *usecase) CheckInWithToken(ctx context.Context, token string, gateID string) (*domain.Ticket, error) {
+	claims, err := VerifyQRToken(token, u.qrPublicKey, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := u.getTicket(ctx, claims.TicketNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if ticket.ID != claims.TicketID || ticket.EventID != claims.EventID {
+		u.log.Warnf("QR claims mismatch for ticket %s", claims.TicketNumber)
+		return nil, domain.ErrInvalidTicketToken
+	}
+
+	return u.redeem(ctx, ticket, gateID)
+}
+
+func (u *usecase) getTicket(ctx context.Context, ticketNumber string) (*domain.Ticket, error) {
+	ticket, err := u.repo.GetTicketByNumber(ctx, ticketNumber)
+	if err != nil {
+		u.log.Errorf("failed to get ticket by number: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	if ticket == nil {
+		return nil, domain.ErrTicketNotFound
+	}
+
+	return ticket, nil
+}
+
+// redeem atomically flips the ticket from VALID to USED and rejects replays
+// and tickets whose order is not COMPLETED
+func (u *usecase) redeem(ctx context.Context, ticket *domain.Ticket, gateID string) (*domain.Ticket, error) {
+	now := time.Now()
+
+	if ticket.Status == domain.TicketStatusRevoked {
+		u.recordScan(ctx, ticket, gateID, domain.TicketScanRejected, domain.ErrTicketRevoked.Error(), now)
+		return nil, domain.ErrTicketRevoked
+	}
+
+	flipped, err := u.repo.MarkTicketUsed(ctx, ticket.ID, gateID, now)
+	if err != nil {
+		u.log.Errorf("failed to mark ticket as used: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	if !flipped {
+		// Someone else already redeemed this ticket, reload to report when and where
+		used, err := u.getTicket(ctx, ticket.TicketNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		if used.Status == domain.TicketStatusRevoked {
+			u.recordScan(ctx, used, gateID, domain.TicketScanRejected, domain.ErrTicketRevoked.Error(), now)
+			return nil, domain.ErrTicketRevoked
+		}
+
+		// Still VALID, so its order is not COMPLETED, e.g. being refunded
+		if used.Status == domain.TicketStatusValid {
+			u.recordScan(ctx, used, gateID, domain.TicketScanRejected, domain.ErrTicketNotActive.Error(), now)
+			return nil, domain.ErrTicketNotActive
+		}
+
+		usedAt := "unknown time"
+		if used.UsedAt != nil {
+			usedAt = used.UsedAt.Format(time.RFC3339)
+		}
+		rejectErr := fmt.Errorf("%w at %s by gate %s", domain.ErrTicketAlreadyUsed, usedAt, used.UsedByGate)
+
+		u.recordScan(ctx, used, gateID, domain.TicketScanRejected, rejectErr.Error(), now)
+		return nil, rejectErr
+	}
+
+	ticket.Status = domain.TicketStatusUsed
+	ticket.UsedAt = &now
+	ticket.UsedByGate = gateID
+
+	u.recordScan(ctx, ticket, gateID, domain.TicketScanAccepted, "", now)
+
+	return ticket, nil
+}
+
+// recordScan stores the scan attempt. A failure here must not undo a
+// successful check-in, so it is only logged.
+func (u *usecase) recordScan(
+	ctx context.Context,
+	ticket *domain.Ticket,
+	gateID string,
+	result domain.TicketScanResult,
+	reason string,
+	scannedAt time.Time,
+) {
+	scannedBy, _ := contextutil.GetUserID(ctx)
+
+	scan := domain.TicketScan{
+		TicketID:  ticket.ID,
+		GateID:    gateID,
+		ScannedBy: scannedBy,
+		Result:    result,
+		Reason:    reason,
+		ScannedAt: scannedAt,
+	}
+
+	if err := u.repo.CreateTicketScan(ctx, &scan); err != nil {
+		u.log.Errorf("failed to record ticket scan: %v", err)
+	}
+}
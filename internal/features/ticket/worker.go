@@ -5,15 +5,19 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/domain"
 	"go-war-ticket-service/internal/features/order"
+	"go-war-ticket-service/internal/platform/idgen"
+	rabbitmq "go-war-ticket-service/internal/platform/message_broker/rabbit_mq"
 	"go-war-ticket-service/internal/platform/pdf"
 	"go-war-ticket-service/internal/utils"
 	"io"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/johnfercher/maroto/pkg/consts"
 	"github.com/minio/minio-go/v7"
 	amqp "github.com/rabbitmq/amqp091-go"
@@ -21,87 +25,105 @@ import (
 )
 
 type TicketWorker struct {
-	mqConn      *amqp.Connection
+	mq          rabbitmq.Publisher
 	repo        Repository
 	orderRepo   order.Repository
 	minioClient *minio.Client
 	cfg         configs.Config
 	pdfGen      pdf.Generator
+	qrSigner    *QRSigner
+	ids         *idgen.Generator
 	log         *zap.SugaredLogger
 }
 
 func NewTicketWorker(
-	conn *amqp.Connection,
+	mq rabbitmq.Publisher,
 	tr Repository,
 	or order.Repository,
 	mc *minio.Client,
 	cfg configs.Config,
 	pg pdf.Generator,
+	qs *QRSigner,
+	ids *idgen.Generator,
 	logger *zap.SugaredLogger,
 ) *TicketWorker {
 	return &TicketWorker{
-		mqConn:      conn,
+		mq:          mq,
 		repo:        tr,
 		orderRepo:   or,
 		minioClient: mc,
 		cfg:         cfg,
 		pdfGen:      pg,
-		log:         logger,
+		qrSigner:    qs,
+		ids:         ids,
+		log:         logger.Named("TicketWorker"),
 	}
 }
 
-func (w *TicketWorker) Start() {
-	ch, _ := w.mqConn.Channel()
-	defer ch.Close()
-
-	// Consume Queue
-	msgs, _ := ch.Consume(
-		utils.QueueTicketGeneration, // Name of queue
-		"",                          // consumer name (empty)
-		false,                       // Auto-Ack (set FALSE to manually Ack after success)
-		false,                       // exclusive
-		false,                       // no-local
-		false,                       // no-wait
-		nil,                         // args
-	)
-
-	// Loop forever waiting for messages
-	forever := make(chan bool)
-
-	go func() {
-		for d := range msgs {
-			if err := w.processMessage(d); err != nil {
-				w.log.Errorf("Error processing message: %v", err)
-				// Option: d.Nack(false, true) if you want to retry
-			}
-		}
-	}()
+// Start generates tickets until ctx is cancelled. Tickets being generated
+// when that happens are finished before it returns.
+func (w *TicketWorker) Start(ctx context.Context) {
+	consumer := rabbitmq.NewConsumer(w.cfg.RabbitMQURL, w.mq, rabbitmq.ConsumerConfig{
+		Queue:       utils.QueueTicketGeneration,
+		Concurrency: w.cfg.TicketWorkerConcurrency,
+		Prefetch:    w.cfg.TicketWorkerPrefetch,
+		Retry: rabbitmq.RetryPolicy{
+			MaxAttempts: w.cfg.QueueMaxAttempts,
+			BaseDelay:   w.cfg.QueueRetryDelay,
+			MaxDelay:    w.cfg.QueueRetryMaxDelay,
+		},
+		OnDeadLetter: w.markFailed,
+	}, w.processMessage, w.log)
 
-	<-forever
+	consumer.Run(ctx)
 }
 
-func (w *TicketWorker) processMessage(d amqp.Delivery) error {
+// processMessage generates the tickets of one order. The consumer acks the
+// delivery on success and retries or dead-letters it on error.
+func (w *TicketWorker) processMessage(ctx context.Context, d amqp.Delivery) error {
+	// The order's own status decides what to do, not the one in the message
 	var payload struct {
-		BookingID     string `json:"booking_id"`
-		PaymentStatus string `json:"payment_status"`
+		BookingID string `json:"booking_id"`
 	}
 
 	if err := json.Unmarshal(d.Body, &payload); err != nil {
-		return err
+		return fmt.Errorf("%w: malformed body: %v", rabbitmq.ErrPermanent, err)
 	}
 
 	w.log.Infof("Processing PDF with Booking ID: %s\n", payload.BookingID)
 
-	order, err := w.orderRepo.GetOrderByBookingID(context.Background(), payload.BookingID)
+	orderData, err := w.orderRepo.GetOrderByBookingID(ctx, payload.BookingID)
 	if err != nil {
 		return err
 	}
 
+	if orderData.ID == uuid.Nil {
+		return fmt.Errorf("%w: order %s not found", rabbitmq.ErrPermanent, payload.BookingID)
+	}
+
+	switch orderData.Status {
+	case domain.OrderStatusCompleted:
+		// Redelivered message for an order whose tickets are all out
+		w.log.Infof("Tickets for Booking ID %s already generated, skipping", payload.BookingID)
+		return nil
+	case domain.OrderStatusPaid, domain.OrderStatusFailed:
+		// FAILED orders come back here when their dead letter is replayed
+		change := order.StatusChange{Actor: order.ActorTicketWorker, Reason: "ticket generation started"}
+		if _, err := w.orderRepo.TransitionOrderStatus(ctx, payload.BookingID, domain.OrderStatusProcessing, change); err != nil {
+			return err
+		}
+	case domain.OrderStatusProcessing:
+		// An earlier attempt was interrupted, carry on from its tickets
+	default:
+		w.log.Warnf("Booking ID %s is %s, not generating tickets", payload.BookingID, orderData.Status)
+		return nil
+	}
+
 	// Get event image
 	object, err := w.minioClient.GetObject(
-		context.Background(),
+		ctx,
 		w.cfg.MinioBucket,
-		order.Event.Image,
+		orderData.Event.Image,
 		minio.GetObjectOptions{},
 	)
 	if err != nil {
@@ -123,71 +145,173 @@ func (w *TicketWorker) processMessage(d amqp.Delivery) error {
 
 	// Get image extension
 	var imgExtension consts.Extension = consts.Jpg // Default
-	if strings.HasSuffix(strings.ToLower(order.Event.Image), ".png") {
+	if strings.HasSuffix(strings.ToLower(orderData.Event.Image), ".png") {
 		imgExtension = consts.Png
 	}
 
-	// Create new ticket
-	for i := 0; i < order.Quantity; i++ {
-		ticketNumber := fmt.Sprintf("TIK-%s-%s", order.BookingID, utils.GenerateRandomNumberString(3))
+	// Fields shared by every ticket of the order
+	base := pdf.TicketData{
+		EventName:        orderData.Event.Name,
+		EventLocation:    orderData.Event.Location,
+		EventDate:        orderData.Event.Date,
+		EventImageBase64: imageBase64,
+		ImageExtension:   imgExtension,
+		OrderID:          orderData.BookingID,
+	}
 
-		// Generate PDF with detail order
-		pdfData := pdf.TicketData{
-			EventName:        order.Event.Name,
-			EventLocation:    order.Event.Location,
-			EventDate:        order.Event.Date,
-			EventImageBase64: imageBase64,
-			ImageExtension:   imgExtension,
-			OrderID:          order.BookingID,
-			TicketCode:       ticketNumber,
-		}
+	// Each order has ticket slots 1..Quantity. Slots filled by an earlier,
+	// interrupted attempt are kept so a retry only generates what is missing.
+	issued := make(map[int]bool, len(orderData.Ticket))
+	for _, ticket := range orderData.Ticket {
+		issued[ticket.Seq] = true
+	}
 
-		pdfBytes, err := w.pdfGen.GenerateTicket(pdfData)
-		if err != nil {
-			w.log.Errorf("failed to generate PDF: %v", err)
-			return err
+	for seq := 1; seq <= orderData.Quantity; seq++ {
+		if issued[seq] {
+			continue
 		}
 
-		// Save PDF to S3
-		filename := fmt.Sprintf("%s.pdf", ticketNumber)
-		objectName := fmt.Sprintf("tickets/%s", filename)
-		reader := bytes.NewReader(pdfBytes)
-
-		_, err = w.minioClient.PutObject(
-			context.Background(),
-			w.cfg.MinioBucket,
-			objectName,
-			reader,
-			int64(len(pdfBytes)),
-			minio.PutObjectOptions{ContentType: "application/pdf"},
-		)
-		if err != nil {
-			w.log.Errorf("failed to upload PDF to S3: %v", err)
+		if err := w.issueTicket(ctx, orderData, seq, base); err != nil {
+			if errors.Is(err, errOrderNotProcessing) {
+				w.log.Warnf("Booking ID %s left PROCESSING during generation, stopping", payload.BookingID)
+				return nil
+			}
 			return err
 		}
+	}
+
+	// Customer notification goes out through the outbox with the completion
+	message, err := domain.NewOutboxMessage(utils.QueueTicketsReady, map[string]interface{}{
+		"booking_id": payload.BookingID,
+	})
+	if err != nil {
+		return err
+	}
 
-		path := objectName
+	// Update order status to completed, checking every slot is filled
+	change := order.StatusChange{Actor: order.ActorTicketWorker, Reason: "tickets generated"}
+	if _, err := w.orderRepo.CompleteOrder(ctx, payload.BookingID, change, message); err != nil {
+		return err
+	}
 
-		ticket := domain.Ticket{
-			OrderID:      order.ID,
-			EventID:      order.EventID,
-			UserID:       order.UserID,
-			TicketNumber: ticketNumber,
-			PDFUrl:       path,
-		}
+	w.log.Infof("PDF generated and saved to S3 for Booking ID: %s\n", payload.BookingID)
+
+	return nil
+}
+
+// markFailed records why ticket generation gave up on an order, right before
+// its job is parked in the dead-letter queue
+func (w *TicketWorker) markFailed(ctx context.Context, d amqp.Delivery, cause error) {
+	var payload struct {
+		BookingID string `json:"booking_id"`
+	}
+	if err := json.Unmarshal(d.Body, &payload); err != nil || payload.BookingID == "" {
+		return
+	}
 
-		if err := w.repo.CreateTicket(context.Background(), &ticket); err != nil {
+	change := order.StatusChange{Actor: order.ActorTicketWorker, Reason: cause.Error()}
+	if _, err := w.orderRepo.TransitionOrderStatus(ctx, payload.BookingID, domain.OrderStatusFailed, change); err != nil {
+		w.log.Errorf("failed to mark Booking ID %s as failed: %v", payload.BookingID, err)
+		return
+	}
+
+	w.log.Errorf("Ticket generation for Booking ID %s failed: %v", payload.BookingID, cause)
+}
+
+// issueTicket fills slot seq, drawing a new ticket number whenever the one
+// generated is already taken
+func (w *TicketWorker) issueTicket(ctx context.Context, orderData *domain.Order, seq int, base pdf.TicketData) error {
+	for attempt := 1; ; attempt++ {
+		err := w.generateTicket(ctx, orderData, seq, base)
+		if !errors.Is(err, errTicketNumberTaken) || attempt == maxTicketNumberAttempts {
 			return err
 		}
+		w.log.Warnf("Ticket number for slot %d of Booking ID %s already taken, drawing another", seq, orderData.BookingID)
 	}
+}
 
-	// Update order status to completed
-	if err := w.orderRepo.UpdateOrderStatus(context.Background(), order.BookingID, domain.OrderStatusCompleted); err != nil {
+// generateTicket renders, uploads and records the ticket in slot seq. If
+// another delivery of the same job filled the slot first, the row is left
+// alone and the PDF uploaded here is removed.
+func (w *TicketWorker) generateTicket(ctx context.Context, orderData *domain.Order, seq int, base pdf.TicketData) error {
+	ticketID := uuid.New()
+	ticketNumber, err := newTicketNumber(w.ids)
+	if err != nil {
 		return err
 	}
 
-	d.Ack(false)
+	// Sign QR payload so gates can verify the ticket offline
+	qrPayload, err := w.qrSigner.Sign(QRClaims{
+		TicketID:     ticketID,
+		EventID:      orderData.EventID,
+		TicketNumber: ticketNumber,
+		ExpiresAt:    orderData.Event.Date.Add(QRTokenGracePeriod).Unix(),
+	})
+	if err != nil {
+		w.log.Errorf("failed to sign QR payload: %v", err)
+		return err
+	}
+
+	// Generate PDF with detail order
+	pdfData := base
+	pdfData.TicketCode = ticketNumber
+	pdfData.QRPayload = qrPayload
+
+	pdfBytes, err := w.pdfGen.GenerateTicket(pdfData)
+	if err != nil {
+		w.log.Errorf("failed to generate PDF: %v", err)
+		return err
+	}
+
+	// Save PDF to S3. The object is named after the ticket ID, which nothing
+	// else can own, so a clashing ticket number never touches another PDF.
+	objectName := fmt.Sprintf("tickets/%s.pdf", ticketID)
+	reader := bytes.NewReader(pdfBytes)
+
+	_, err = w.minioClient.PutObject(
+		ctx,
+		w.cfg.MinioBucket,
+		objectName,
+		reader,
+		int64(len(pdfBytes)),
+		minio.PutObjectOptions{ContentType: "application/pdf"},
+	)
+	if err != nil {
+		w.log.Errorf("failed to upload PDF to S3: %v", err)
+		return err
+	}
+
+	ticket := domain.Ticket{
+		BaseModel:    domain.BaseModel{ID: ticketID},
+		OrderID:      orderData.ID,
+		EventID:      orderData.EventID,
+		UserID:       orderData.UserID,
+		Seq:          seq,
+		TicketNumber: ticketNumber,
+		PDFUrl:       objectName,
+		Status:       domain.TicketStatusValid,
+	}
+
+	created, err := w.repo.CreateTicket(ctx, &ticket)
+	if err != nil {
+		if errors.Is(err, errTicketNumberTaken) || errors.Is(err, errOrderNotProcessing) {
+			w.removePDF(ctx, objectName)
+		}
+		return err
+	}
+	if !created {
+		w.log.Warnf("Ticket slot %d of Booking ID %s was already filled, discarding %s", seq, orderData.BookingID, ticketNumber)
+		w.removePDF(ctx, objectName)
+	}
 
-	w.log.Infof("PDF generated and saved to S3 for Booking ID: %s\n", payload.BookingID)
 	return nil
 }
+
+// removePDF drops a PDF no ticket row points at. Objects are named after a
+// ticket ID only the calling attempt ever used, so no live ticket loses its
+// PDF.
+func (w *TicketWorker) removePDF(ctx context.Context, objectName string) {
+	if err := w.minioClient.RemoveObject(ctx, w.cfg.MinioBucket, objectName, minio.RemoveObjectOptions{}); err != nil {
+		w.log.Warnf("failed to remove PDF %s: %v", objectName, err)
+	}
+}
@@ -8,4 +8,11 @@ type UserResponse struct {
 	FullName string    `json:"full_name"`
 	Username string    `json:"username"`
 	Avatar   string    `json:"avatar"`
+	Role     string    `json:"role"`
+
+	EmailVerified bool `json:"email_verified"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=customer organiser admin gate_staff"`
 }
@@ -3,18 +3,22 @@ package user
 import (
 	"go-war-ticket-service/internal/domain"
 	"go-war-ticket-service/internal/platform/responses"
+	"go-war-ticket-service/internal/platform/validator"
 	"go-war-ticket-service/internal/utils/contextutil"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
-	usecase Usecase
+	usecase   Usecase
+	validator *validator.Validator
 }
 
-func NewHandler(uc Usecase) *Handler {
+func NewHandler(uc Usecase, validator *validator.Validator) *Handler {
 	return &Handler{
-		usecase: uc,
+		usecase:   uc,
+		validator: validator,
 	}
 }
 
@@ -35,6 +39,44 @@ func (h *Handler) GetMyProfile(c *fiber.Ctx) error {
 		Username: res.Username,
 		Email:    res.Email,
 		Avatar:   res.Avatar,
+		Role:     string(res.Role),
+
+		EmailVerified: res.IsEmailVerified(),
+	}
+
+	return responses.Success(c, response, "success")
+}
+
+func (h *Handler) UpdateUserRole(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("user_id"))
+	if err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidID.Error())
+	}
+
+	var req UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := h.validator.Validate(req); err != nil {
+		errors := h.validator.FormatErrors(err)
+		return responses.ValidationError(c, errors)
+	}
+
+	res, err := h.usecase.UpdateUserRole(c.Context(), userID, domain.UserRole(req.Role))
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	response := UserResponse{
+		ID:       res.ID,
+		FullName: res.FullName,
+		Username: res.Username,
+		Email:    res.Email,
+		Avatar:   res.Avatar,
+		Role:     string(res.Role),
+
+		EmailVerified: res.IsEmailVerified(),
 	}
 
 	return responses.Success(c, response, "success")
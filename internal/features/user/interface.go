@@ -9,6 +9,7 @@ import (
 
 type Usecase interface {
 	GetProfile(ctx context.Context, userID uuid.UUID) (*domain.User, error)
+	UpdateUserRole(ctx context.Context, userID uuid.UUID, role domain.UserRole) (*domain.User, error)
 }
 
 type Repository interface {
@@ -16,4 +17,5 @@ type Repository interface {
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*domain.User, error)
+	UpdateUserRole(ctx context.Context, userID uuid.UUID, role domain.UserRole) error
 }
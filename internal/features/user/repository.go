@@ -60,3 +60,7 @@ func (r *repository) GetUserByID(ctx context.Context, userID uuid.UUID) (*domain
 	}
 	return &user, nil
 }
+
+func (r *repository) UpdateUserRole(ctx context.Context, userID uuid.UUID, role domain.UserRole) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).Where("id = ?", userID).Update("role", role).Error
+}
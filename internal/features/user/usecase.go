@@ -5,6 +5,7 @@ import (
 	"go-war-ticket-service/configs"
 	"go-war-ticket-service/internal/domain"
 	"go-war-ticket-service/internal/platform/storage"
+	"go-war-ticket-service/internal/utils/contextutil"
 	"time"
 
 	"github.com/google/uuid"
@@ -63,3 +64,33 @@ func (u *usecase) GetProfile(ctx context.Context, userID uuid.UUID) (*domain.Use
 
 	return user, nil
 }
+
+// UpdateUserRole changes a user's role. It applies from the next token the
+// user is issued, so an existing access token keeps the old role until it
+// expires.
+func (u *usecase) UpdateUserRole(ctx context.Context, userID uuid.UUID, role domain.UserRole) (*domain.User, error) {
+	if !role.IsValid() {
+		return nil, domain.ErrInvalidRole
+	}
+
+	user, err := u.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		u.log.Errorf("failed to get user by ID: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	if err := u.repo.UpdateUserRole(ctx, userID, role); err != nil {
+		u.log.Errorf("failed to update role of user %s: %v", userID, err)
+		return nil, domain.ErrInternal
+	}
+
+	actorID, _ := contextutil.GetUserID(ctx)
+	u.log.Infof("user %s changed role of user %s from %s to %s", actorID, userID, user.Role, role)
+
+	user.Role = role
+	return user, nil
+}
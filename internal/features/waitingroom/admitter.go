@@ -0,0 +1,180 @@
+package waitingroom
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/utils"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAdmitRate     = 100
+	defaultAdmitInterval = time.Second
+	defaultAdmissionTTL  = 10 * time.Minute
+	defaultQueueTTL      = 5 * time.Minute
+)
+
+// dropEmptyScript forgets an event once its queue is drained. It runs as a
+// script so a user joining in between can't be left in an untracked queue.
+var dropEmptyScript = redis.NewScript(`
+if redis.call("ZCARD", KEYS[1]) == 0 then
+	redis.call("SREM", KEYS[2], ARGV[1])
+end
+return 0
+`)
+
+// roomConfig is the waiting room config with defaults filled in
+type roomConfig struct {
+	admitRate     int
+	admitInterval time.Duration
+	admissionTTL  time.Duration
+	queueTTL      time.Duration
+}
+
+func newRoomConfig(cfg configs.Config) roomConfig {
+	room := roomConfig{
+		admitRate:     cfg.WaitingRoomAdmitRate,
+		admitInterval: cfg.WaitingRoomAdmitInterval,
+		admissionTTL:  cfg.WaitingRoomAdmissionTTL,
+		queueTTL:      cfg.WaitingRoomQueueTTL,
+	}
+
+	if room.admitRate <= 0 {
+		room.admitRate = defaultAdmitRate
+	}
+	if room.admitInterval <= 0 {
+		room.admitInterval = defaultAdmitInterval
+	}
+	if room.admissionTTL <= 0 {
+		room.admissionTTL = defaultAdmissionTTL
+	}
+	if room.queueTTL <= 0 {
+		room.queueTTL = defaultQueueTTL
+	}
+
+	return room
+}
+
+// estimatedWait is how long until the user at position gets admitted
+func (r roomConfig) estimatedWait(position int64) time.Duration {
+	rate := int64(r.admitRate)
+	return time.Duration((position+rate-1)/rate) * r.admitInterval
+}
+
+// Admitter lets the next batch of queued users in for every event with a
+// queue, at most admitRate per event each interval
+type Admitter struct {
+	cache *redis.Client
+	room  roomConfig
+	log   *zap.SugaredLogger
+}
+
+func NewAdmitter(cache *redis.Client, cfg configs.Config, log *zap.SugaredLogger) *Admitter {
+	return &Admitter{
+		cache: cache,
+		room:  newRoomConfig(cfg),
+		log:   log.Named("WaitingRoomAdmitter"),
+	}
+}
+
+// Start admits waiting users every interval until ctx is cancelled
+func (a *Admitter) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.room.admitInterval)
+	defer ticker.Stop()
+
+	for {
+		a.admit(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Admitter) admit(ctx context.Context) {
+	// Every API instance runs an admitter, only one of them may drain the
+	// queues per interval or the admission rate would multiply
+	acquired, err := a.cache.SetNX(ctx, utils.WaitingRoomAdmitterLockKey, "1", a.room.admitInterval).Result()
+	if err != nil {
+		a.log.Errorf("failed to acquire admitter lock: %v", err)
+		return
+	}
+
+	if !acquired {
+		return
+	}
+
+	events, err := a.cache.SMembers(ctx, utils.WaitingRoomEventsKey).Result()
+	if err != nil {
+		a.log.Errorf("failed to list waiting rooms: %v", err)
+		return
+	}
+
+	for _, eventID := range events {
+		a.admitEvent(ctx, eventID)
+	}
+}
+
+func (a *Admitter) admitEvent(ctx context.Context, eventID string) {
+	queueKey := fmt.Sprintf(utils.WaitingRoomQueueKey, eventID)
+	admitted := 0
+
+	for admitted < a.room.admitRate {
+		popped, err := a.cache.ZPopMin(ctx, queueKey, int64(a.room.admitRate-admitted)).Result()
+		if err != nil {
+			a.log.Errorf("failed to pop waiting room %s: %v", eventID, err)
+			return
+		}
+
+		if len(popped) == 0 {
+			break
+		}
+
+		for _, z := range popped {
+			userID, _ := z.Member.(string)
+			if a.admitUser(ctx, eventID, userID) {
+				admitted++
+			}
+		}
+	}
+
+	if err := dropEmptyScript.Run(ctx, a.cache, []string{queueKey, utils.WaitingRoomEventsKey}, eventID).Err(); err != nil {
+		a.log.Warnf("failed to clean up waiting room %s: %v", eventID, err)
+	}
+
+	if admitted > 0 {
+		a.log.Infof("Admitted %d users to event %s", admitted, eventID)
+	}
+}
+
+// admitUser turns a queued entry into an admission. Users whose entry has
+// expired stopped polling and are skipped.
+func (a *Admitter) admitUser(ctx context.Context, eventID, userID string) bool {
+	entry := entryKey(eventID, userID)
+
+	token, err := a.cache.Get(ctx, entry).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			a.log.Errorf("failed to get waiting room entry for user %s: %v", userID, err)
+		}
+		return false
+	}
+
+	pipe := a.cache.TxPipeline()
+	pipe.Set(ctx, admittedKey(eventID, userID), token, a.room.admissionTTL)
+	pipe.Expire(ctx, entry, a.room.admissionTTL)
+	pipe.Expire(ctx, tokenKey(token), a.room.admissionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		a.log.Errorf("failed to admit user %s to event %s: %v", userID, eventID, err)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,16 @@
+package waitingroom
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PlaceResponse struct {
+	EventID              uuid.UUID  `json:"event_id"`
+	Status               Status     `json:"status"`
+	Position             int64      `json:"position"`
+	AdmissionToken       string     `json:"admission_token"`
+	EstimatedWaitSeconds int64      `json:"estimated_wait_seconds"`
+	AdmittedUntil        *time.Time `json:"admitted_until,omitempty"`
+}
@@ -0,0 +1,71 @@
+package waitingroom
+
+import (
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/responses"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type Handler struct {
+	usecase Usecase
+}
+
+func NewHandler(uc Usecase) *Handler {
+	return &Handler{
+		usecase: uc,
+	}
+}
+
+func (h *Handler) Join(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("event_id"))
+	if err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidID.Error())
+	}
+
+	place, err := h.usecase.Join(c.Context(), eventID)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, toPlaceResponse(*place), "success")
+}
+
+func (h *Handler) GetPlace(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("event_id"))
+	if err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidID.Error())
+	}
+
+	place, err := h.usecase.GetPlace(c.Context(), eventID)
+	if err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, toPlaceResponse(*place), "success")
+}
+
+func (h *Handler) Leave(c *fiber.Ctx) error {
+	eventID, err := uuid.Parse(c.Params("event_id"))
+	if err != nil {
+		return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidID.Error())
+	}
+
+	if err := h.usecase.Leave(c.Context(), eventID); err != nil {
+		return responses.UsecaseError(c, err)
+	}
+
+	return responses.Success(c, nil, "success")
+}
+
+func toPlaceResponse(place Place) PlaceResponse {
+	return PlaceResponse{
+		EventID:              place.EventID,
+		Status:               place.Status,
+		Position:             place.Position,
+		AdmissionToken:       place.Token,
+		EstimatedWaitSeconds: int64(place.EstimatedWait.Seconds()),
+		AdmittedUntil:        place.AdmittedUntil,
+	}
+}
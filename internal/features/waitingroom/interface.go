@@ -0,0 +1,38 @@
+package waitingroom
+
+import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Status string
+
+const (
+	StatusWaiting  Status = "WAITING"
+	StatusAdmitted Status = "ADMITTED"
+)
+
+// Place is where a user stands in an event's waiting room. Token is what the
+// user sends as X-Admission-Token once Status is ADMITTED.
+type Place struct {
+	EventID       uuid.UUID
+	Status        Status
+	Position      int64 // 1-based place in line, 0 once admitted
+	Token         string
+	EstimatedWait time.Duration
+	AdmittedUntil *time.Time
+}
+
+type Usecase interface {
+	Join(ctx context.Context, eventID uuid.UUID) (*Place, error)
+	GetPlace(ctx context.Context, eventID uuid.UUID) (*Place, error)
+	Leave(ctx context.Context, eventID uuid.UUID) error
+	CheckAdmission(ctx context.Context, userID uuid.UUID, token string) (uuid.UUID, error)
+}
+
+type Repository interface {
+	GetEventByID(ctx context.Context, eventID uuid.UUID) (*domain.Event, error)
+}
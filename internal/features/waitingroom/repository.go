@@ -0,0 +1,31 @@
+package waitingroom
+
+import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{
+		db: db,
+	}
+}
+
+func (r *repository) GetEventByID(ctx context.Context, eventID uuid.UUID) (*domain.Event, error) {
+	var event domain.Event
+	err := r.db.WithContext(ctx).Where("id = ?", eventID).First(&event).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
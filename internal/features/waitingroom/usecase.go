@@ -0,0 +1,245 @@
+package waitingroom
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-war-ticket-service/configs"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/utils"
+	"go-war-ticket-service/internal/utils/contextutil"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// joinScript queues a user once. Joining again while queued or admitted
+// returns the token handed out the first time and keeps the place in line.
+//
+// KEYS: entry, queue, join counter, token lookup, active events
+// ARGV: token, user id, entry TTL in seconds, token lookup value, event id
+var joinScript = redis.NewScript(`
+local existing = redis.call("GET", KEYS[1])
+if existing then
+	return existing
+end
+redis.call("SET", KEYS[1], ARGV[1], "EX", ARGV[3])
+redis.call("SET", KEYS[4], ARGV[4], "EX", ARGV[3])
+local seq = redis.call("INCR", KEYS[3])
+redis.call("ZADD", KEYS[2], seq, ARGV[2])
+redis.call("SADD", KEYS[5], ARGV[5])
+return ARGV[1]
+`)
+
+type usecase struct {
+	repo  Repository
+	cache *redis.Client
+	cfg   configs.Config
+	room  roomConfig
+	log   *zap.SugaredLogger
+}
+
+func NewUsecase(
+	r Repository,
+	cache *redis.Client,
+	cfg configs.Config,
+	log *zap.SugaredLogger,
+) Usecase {
+	return &usecase{
+		repo:  r,
+		cache: cache,
+		cfg:   cfg,
+		room:  newRoomConfig(cfg),
+		log:   log.Named("WaitingRoomUsecase"),
+	}
+}
+
+func (u *usecase) Join(ctx context.Context, eventID uuid.UUID) (*Place, error) {
+	if !u.cfg.WaitingRoomEnabled {
+		return nil, domain.ErrWaitingRoomClosed
+	}
+
+	userID, _ := contextutil.GetUserID(ctx)
+
+	event, err := u.repo.GetEventByID(ctx, eventID)
+	if err != nil {
+		u.log.Errorf("failed to get event: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	if event == nil {
+		return nil, domain.ErrEventNotFound
+	}
+
+	token, err := newAdmissionToken()
+	if err != nil {
+		u.log.Errorf("failed to generate admission token: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	keys := []string{
+		entryKey(eventID.String(), userID.String()),
+		fmt.Sprintf(utils.WaitingRoomQueueKey, eventID.String()),
+		fmt.Sprintf(utils.WaitingRoomSeqKey, eventID.String()),
+		tokenKey(token),
+		utils.WaitingRoomEventsKey,
+	}
+	args := []interface{}{
+		token,
+		userID.String(),
+		int(u.room.queueTTL.Seconds()),
+		eventID.String() + ":" + userID.String(),
+		eventID.String(),
+	}
+
+	if err := joinScript.Run(ctx, u.cache, keys, args...).Err(); err != nil {
+		u.log.Errorf("failed to join waiting room: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	return u.GetPlace(ctx, eventID)
+}
+
+// GetPlace reports the user's position. Clients are expected to poll it,
+// every poll keeps a queued user's entry alive for another queue TTL.
+func (u *usecase) GetPlace(ctx context.Context, eventID uuid.UUID) (*Place, error) {
+	if !u.cfg.WaitingRoomEnabled {
+		return nil, domain.ErrWaitingRoomClosed
+	}
+
+	userID, _ := contextutil.GetUserID(ctx)
+	entry := entryKey(eventID.String(), userID.String())
+
+	token, err := u.cache.Get(ctx, entry).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, domain.ErrNotInWaitingRoom
+		}
+		u.log.Errorf("failed to get waiting room entry: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	ttl, err := u.cache.TTL(ctx, admittedKey(eventID.String(), userID.String())).Result()
+	if err != nil {
+		u.log.Errorf("failed to get admission: %v", err)
+		return nil, domain.ErrInternal
+	}
+
+	if ttl > 0 {
+		until := time.Now().Add(ttl)
+		return &Place{
+			EventID:       eventID,
+			Status:        StatusAdmitted,
+			Token:         token,
+			AdmittedUntil: &until,
+		}, nil
+	}
+
+	rank, err := u.cache.ZRank(ctx, fmt.Sprintf(utils.WaitingRoomQueueKey, eventID.String()), userID.String()).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		u.log.Errorf("failed to get waiting room position: %v", err)
+		return nil, domain.ErrInternal
+	}
+	// redis.Nil here means the admitter has just taken the user off the queue
+
+	pipe := u.cache.Pipeline()
+	pipe.Expire(ctx, entry, u.room.queueTTL)
+	pipe.Expire(ctx, tokenKey(token), u.room.queueTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		u.log.Warnf("failed to refresh waiting room entry: %v", err)
+	}
+
+	position := rank + 1
+	return &Place{
+		EventID:       eventID,
+		Status:        StatusWaiting,
+		Position:      position,
+		Token:         token,
+		EstimatedWait: u.room.estimatedWait(position),
+	}, nil
+}
+
+func (u *usecase) Leave(ctx context.Context, eventID uuid.UUID) error {
+	if !u.cfg.WaitingRoomEnabled {
+		return domain.ErrWaitingRoomClosed
+	}
+
+	userID, _ := contextutil.GetUserID(ctx)
+	entry := entryKey(eventID.String(), userID.String())
+
+	token, err := u.cache.Get(ctx, entry).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return domain.ErrNotInWaitingRoom
+		}
+		u.log.Errorf("failed to get waiting room entry: %v", err)
+		return domain.ErrInternal
+	}
+
+	pipe := u.cache.TxPipeline()
+	pipe.ZRem(ctx, fmt.Sprintf(utils.WaitingRoomQueueKey, eventID.String()), userID.String())
+	pipe.Del(ctx, entry, tokenKey(token), admittedKey(eventID.String(), userID.String()))
+	if _, err := pipe.Exec(ctx); err != nil {
+		u.log.Errorf("failed to leave waiting room: %v", err)
+		return domain.ErrInternal
+	}
+
+	return nil
+}
+
+// CheckAdmission backs the admission middleware. The token has to belong to
+// the user and still be the one admitted for its event.
+func (u *usecase) CheckAdmission(ctx context.Context, userID uuid.UUID, token string) (uuid.UUID, error) {
+	owner, err := u.cache.Get(ctx, tokenKey(token)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return uuid.Nil, domain.ErrAdmissionRequired
+		}
+		return uuid.Nil, err
+	}
+
+	eventPart, userPart, _ := strings.Cut(owner, ":")
+	eventID, err := uuid.Parse(eventPart)
+	if err != nil || userPart != userID.String() {
+		return uuid.Nil, domain.ErrAdmissionRequired
+	}
+
+	admitted, err := u.cache.Get(ctx, admittedKey(eventPart, userPart)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return uuid.Nil, domain.ErrAdmissionRequired
+		}
+		return uuid.Nil, err
+	}
+
+	if admitted != token {
+		return uuid.Nil, domain.ErrAdmissionRequired
+	}
+
+	return eventID, nil
+}
+
+func newAdmissionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func entryKey(eventID, userID string) string {
+	return fmt.Sprintf(utils.WaitingRoomEntryKey, eventID, userID)
+}
+
+func admittedKey(eventID, userID string) string {
+	return fmt.Sprintf(utils.WaitingRoomAdmittedKey, eventID, userID)
+}
+
+func tokenKey(token string) string {
+	return fmt.Sprintf(utils.WaitingRoomTokenKey, token)
+}
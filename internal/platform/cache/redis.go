@@ -25,3 +25,22 @@ func NewRedisClient(cfg configs.Config) (*redis.Client, error) {
 
 	return rdb, nil
 }
+
+// incrByIfExistsScript only adjusts a counter that is already cached. A
+// missing key is re-hydrated from the DB on next use, so creating it here with
+// just the delta would misreport the real value.
+var incrByIfExistsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("INCRBY", KEYS[1], ARGV[1])
+end
+return false
+`)
+
+// IncrByIfExists atomically adds delta (which may be negative) to key when it exists
+func IncrByIfExists(ctx context.Context, client *redis.Client, key string, delta int) error {
+	err := incrByIfExistsScript.Run(ctx, client, []string{key}, delta).Err()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+	return nil
+}
@@ -13,7 +13,9 @@ func Connect(cfg configs.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=Asia/Jakarta",
 		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort, cfg.DBSSLMode)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError turns unique violations into gorm.ErrDuplicatedKey, which
+	// callers retry on when a generated ID collides
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
@@ -22,9 +24,16 @@ func Connect(cfg configs.Config) (*gorm.DB, error) {
 	if cfg.ServerMode == "development" {
 		err := db.AutoMigrate(
 			&domain.User{},
+			&domain.RefreshToken{},
+			&domain.UserToken{},
 			&domain.Event{},
+			&domain.TicketTier{},
 			&domain.Order{},
+			&domain.OrderStatusHistory{},
 			&domain.Ticket{},
+			&domain.TicketScan{},
+			&domain.EmailDelivery{},
+			&domain.OutboxMessage{},
 		)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,128 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// Crockford is Crockford's base32 alphabet. It leaves out I, L, O and U so
+// codes that are read out or typed in by hand are hard to get wrong.
+const Crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// crockfordAliases are the look-alike characters Crockford decodes as digits
+var crockfordAliases = map[rune]rune{'I': '1', 'L': '1', 'O': '0'}
+
+// Generator makes random codes from crypto/rand over a fixed alphabet, each
+// ending in a Luhn mod N check character so typos are caught before lookup
+type Generator struct {
+	alphabet []rune
+	index    map[rune]int
+	aliases  map[rune]rune
+	max      *big.Int
+}
+
+// New returns a generator over alphabet, which needs at least two distinct
+// characters
+func New(alphabet string) (*Generator, error) {
+	chars := []rune(alphabet)
+	if len(chars) < 2 {
+		return nil, fmt.Errorf("alphabet needs at least 2 characters, got %d", len(chars))
+	}
+
+	index := make(map[rune]int, len(chars))
+	for i, c := range chars {
+		if _, ok := index[c]; ok {
+			return nil, fmt.Errorf("alphabet repeats %q", c)
+		}
+		index[c] = i
+	}
+
+	return &Generator{
+		alphabet: chars,
+		index:    index,
+		max:      big.NewInt(int64(len(chars))),
+	}, nil
+}
+
+// NewCrockford returns a generator over Crockford base32 that also accepts
+// lower case and the I, L and O look-alikes when validating
+func NewCrockford() *Generator {
+	g, _ := New(Crockford)
+	g.aliases = crockfordAliases
+	return g
+}
+
+// Generate returns n random characters followed by their check character
+func (g *Generator) Generate(n int) (string, error) {
+	code := make([]rune, n, n+1)
+	for i := range code {
+		v, err := rand.Int(rand.Reader, g.max)
+		if err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		code[i] = g.alphabet[v.Int64()]
+	}
+
+	return string(append(code, g.checkChar(code))), nil
+}
+
+// Normalize maps a hand-typed code onto the alphabet: surrounding spaces are
+// dropped and, for alphabets with aliases, case and look-alikes are folded
+func (g *Generator) Normalize(code string) string {
+	code = strings.TrimSpace(code)
+	if g.aliases == nil {
+		return code
+	}
+
+	return strings.Map(func(c rune) rune {
+		c = unicode.ToUpper(c)
+		if alias, ok := g.aliases[c]; ok {
+			return alias
+		}
+		return c
+	}, code)
+}
+
+// Valid reports whether code, as returned by Normalize, only uses the
+// alphabet and ends in the right check character
+func (g *Generator) Valid(code string) bool {
+	chars := []rune(code)
+	if len(chars) < 2 {
+		return false
+	}
+
+	for _, c := range chars {
+		if _, ok := g.index[c]; !ok {
+			return false
+		}
+	}
+
+	body := chars[:len(chars)-1]
+	return g.checkChar(body) == chars[len(chars)-1]
+}
+
+// checkChar computes the Luhn mod N check character of code, which must only
+// contain alphabet characters
+func (g *Generator) checkChar(code []rune) rune {
+	n := len(g.alphabet)
+	factor := 2
+	sum := 0
+
+	// Walk right to left, doubling every other code point starting with the
+	// rightmost, and fold each product back into base n
+	for i := len(code) - 1; i >= 0; i-- {
+		addend := factor * g.index[code[i]]
+		sum += addend/n + addend%n
+
+		if factor == 2 {
+			factor = 1
+		} else {
+			factor = 2
+		}
+	}
+
+	return g.alphabet[(n-sum%n)%n]
+}
@@ -0,0 +1,94 @@
+package idgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckCharMatchesLuhn(t *testing.T) {
+	// Over the decimal alphabet Luhn mod N is the classic Luhn algorithm
+	g, err := New("0123456789")
+	if err != nil {
+		t.Fatalf("new generator: %v", err)
+	}
+
+	if !g.Valid("79927398713") {
+		t.Fatalf("expected the textbook Luhn number to be valid")
+	}
+	if g.Valid("79927398710") {
+		t.Fatalf("expected a wrong check digit to be rejected")
+	}
+}
+
+func TestGeneratedCodesAreValid(t *testing.T) {
+	g := NewCrockford()
+
+	for i := 0; i < 1000; i++ {
+		code, err := g.Generate(10)
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		if len(code) != 11 {
+			t.Fatalf("expected 10 characters plus a check character, got %q", code)
+		}
+		if strings.ContainsAny(code, "ILOU") {
+			t.Fatalf("code %q uses a character outside Crockford base32", code)
+		}
+		if !g.Valid(code) {
+			t.Fatalf("generated code %q is not valid", code)
+		}
+	}
+}
+
+func TestValidCatchesSingleCharacterTypos(t *testing.T) {
+	g := NewCrockford()
+
+	code, err := g.Generate(12)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	chars := []rune(code)
+	for i := range chars {
+		for _, c := range Crockford {
+			if c == chars[i] {
+				continue
+			}
+
+			typo := append([]rune(nil), chars...)
+			typo[i] = c
+			if g.Valid(string(typo)) {
+				t.Fatalf("typo %q of %q was accepted", string(typo), code)
+			}
+		}
+	}
+}
+
+func TestNormalizeFoldsLookAlikes(t *testing.T) {
+	g := NewCrockford()
+
+	code, err := g.Generate(10)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	// A customer reading the code back in lower case, with O for 0 and l for 1
+	typed := strings.NewReplacer("0", "o", "1", "l").Replace(strings.ToLower(code))
+	if got := g.Normalize("  " + typed + " "); got != code {
+		t.Fatalf("Normalize(%q) = %q, want %q", typed, got, code)
+	}
+
+	// Alphabets without aliases are only trimmed
+	plain, _ := New("abc")
+	if got := plain.Normalize(" abC "); got != "abC" {
+		t.Fatalf("expected only trimming without aliases, got %q", got)
+	}
+}
+
+func TestNewRejectsBadAlphabets(t *testing.T) {
+	for _, alphabet := range []string{"", "A", "ABCA"} {
+		if _, err := New(alphabet); err == nil {
+			t.Errorf("expected alphabet %q to be rejected", alphabet)
+		}
+	}
+}
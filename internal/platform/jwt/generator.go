@@ -1,26 +1,41 @@
 package jwt
 
 import (
+	"go-war-ticket-service/internal/domain"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+const defaultAccessTokenTTL = time.Hour
+
 // JWTGenerator adalah implementasi konkret dari auth.TokenGenerator
 type JWTGenerator struct {
 	secretKey string
+	accessTTL time.Duration
+}
+
+func NewJWTGenerator(secret string, accessTTL time.Duration) *JWTGenerator {
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTokenTTL
+	}
+	return &JWTGenerator{secretKey: secret, accessTTL: accessTTL}
 }
 
-func NewJWTGenerator(secret string) *JWTGenerator {
-	return &JWTGenerator{secretKey: secret}
+// AccessTTL is how long issued access tokens stay valid
+func (j *JWTGenerator) AccessTTL() time.Duration {
+	return j.accessTTL
 }
 
-func (j *JWTGenerator) GenerateToken(userID uuid.UUID) (string, error) {
+func (j *JWTGenerator) GenerateToken(userID uuid.UUID, role domain.UserRole) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
-		"sub": userID.String(), // 'sub' (subject) adalah standar untuk ID user
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(time.Hour * 1).Unix(), // Token berlaku 1 jam
+		"sub":  userID.String(), // 'sub' (subject) adalah standar untuk ID user
+		"role": string(role),
+		"jti":  uuid.NewString(), // lets a single token be revoked on logout
+		"iat":  now.Unix(),
+		"exp":  now.Add(j.accessTTL).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -0,0 +1,140 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mailer sends transactional email such as verification links and tickets
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+type Message struct {
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string // optional, sent as an alternative to Text
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// build renders msg as a MIME message ready for SMTP DATA or an .eml file
+func build(from string, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(msg.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%s@%s>", uuid.NewString(), domainOf(from)),
+		"MIME-Version: 1.0",
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	headers = append(headers, fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", mixed.Boundary()))
+	header := strings.Join(headers, "\r\n") + "\r\n\r\n"
+
+	if err := writeBody(mixed, msg); err != nil {
+		return nil, err
+	}
+
+	for _, a := range msg.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", contentType, a.Filename)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", a.Filename)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if err := writeBase64(part, a.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	return append([]byte(header), buf.Bytes()...), nil
+}
+
+func writeBody(mixed *multipart.Writer, msg Message) error {
+	var alt bytes.Buffer
+	altWriter := multipart.NewWriter(&alt)
+
+	bodies := []struct{ contentType, content string }{{"text/plain", msg.Text}}
+	if msg.HTML != "" {
+		bodies = append(bodies, struct{ contentType, content string }{"text/html", msg.HTML})
+	}
+
+	for _, body := range bodies {
+		part, err := altWriter.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return err
+		}
+		if err := writeBase64(part, []byte(body.content)); err != nil {
+			return err
+		}
+	}
+
+	if err := altWriter.Close(); err != nil {
+		return err
+	}
+
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", altWriter.Boundary())},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = part.Write(alt.Bytes())
+	return err
+}
+
+// writeBase64 wraps encoded lines at 76 characters as RFC 2045 requires
+func writeBase64(w interface{ Write([]byte) (int, error) }, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		if _, err := w.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err := w.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+func domainOf(address string) string {
+	address = strings.TrimSuffix(strings.TrimSpace(address), ">")
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+	return "localhost"
+}
@@ -0,0 +1,187 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultConcurrency  = 1
+	minReconnectBackoff = time.Second
+	maxReconnectBackoff = 30 * time.Second
+)
+
+// Handler processes one delivery. Returning nil acks it, an error hands it
+// to the retry policy. The context is not cancelled on shutdown, so work
+// that has started gets to finish.
+type Handler func(ctx context.Context, d amqp.Delivery) error
+
+type ConsumerConfig struct {
+	Queue       string
+	Concurrency int // deliveries processed at once
+	Prefetch    int // unacked deliveries the broker may push ahead, defaults to twice the concurrency
+	Retry       RetryPolicy
+	// OnDeadLetter, if set, runs before a delivery is parked in the
+	// dead-letter queue, with the error of its last attempt
+	OnDeadLetter func(ctx context.Context, d amqp.Delivery, cause error)
+}
+
+// Consumer runs a pool of goroutines over one queue on its own connection,
+// and reconnects with backoff whenever the broker drops it
+type Consumer struct {
+	url       string
+	publisher Publisher
+	cfg       ConsumerConfig
+	handler   Handler
+	log       *zap.SugaredLogger
+}
+
+func NewConsumer(url string, publisher Publisher, cfg ConsumerConfig, handler Handler, log *zap.SugaredLogger) *Consumer {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = defaultConcurrency
+	}
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = cfg.Concurrency * 2
+	}
+
+	return &Consumer{
+		url:       url,
+		publisher: publisher,
+		cfg:       cfg,
+		handler:   handler,
+		log:       log,
+	}
+}
+
+// Run consumes until ctx is cancelled, then stops taking new deliveries and
+// returns once the in-flight ones are done
+func (c *Consumer) Run(ctx context.Context) {
+	backoff := minReconnectBackoff
+
+	for {
+		started := time.Now()
+		err := c.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A session that lasted a while was healthy, start backing off afresh
+		if time.Since(started) > maxReconnectBackoff {
+			backoff = minReconnectBackoff
+		}
+
+		c.log.Warnf("Consumer for %s stopped: %v, reconnecting in %s", c.cfg.Queue, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxReconnectBackoff)
+	}
+}
+
+// consume runs one session on a fresh connection until ctx is cancelled or
+// the connection, the channel or the consumer itself is lost
+func (c *Consumer) consume(ctx context.Context) error {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	defer conn.Close()
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	consumerTag := fmt.Sprintf("%s-%d", c.cfg.Queue, time.Now().UnixNano())
+	deliveries, err := ch.Consume(
+		c.cfg.Queue, // name of queue
+		consumerTag, // consumer tag
+		false,       // auto-ack
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to consume %s: %w", c.cfg.Queue, err)
+	}
+
+	// The channel can die on its own (a failed ack, a deleted queue) while the
+	// connection stays up, so watch both along with a server-side cancel
+	connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	cancelled := ch.NotifyCancel(make(chan string, 1))
+	c.log.Infof("Consuming %s with %d workers, prefetch %d", c.cfg.Queue, c.cfg.Concurrency, c.cfg.Prefetch)
+
+	// Handlers outlive a shutdown signal so PDFs being rendered are finished
+	handlerCtx := context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				c.handle(handlerCtx, d)
+			}
+		}()
+	}
+
+	select {
+	case <-ctx.Done():
+		// Stop new deliveries, prefetched ones go back to the queue when the
+		// channel closes
+		if err := ch.Cancel(consumerTag, false); err != nil {
+			c.log.Warnf("failed to cancel consumer for %s: %v", c.cfg.Queue, err)
+		}
+		wg.Wait()
+		c.log.Infof("Consumer for %s drained", c.cfg.Queue)
+		return nil
+	case amqpErr := <-connClosed:
+		wg.Wait()
+		if amqpErr == nil {
+			return errors.New("connection closed")
+		}
+		return amqpErr
+	case amqpErr := <-chClosed:
+		wg.Wait()
+		if amqpErr == nil {
+			return errors.New("channel closed")
+		}
+		return amqpErr
+	case tag := <-cancelled:
+		wg.Wait()
+		return fmt.Errorf("consumer %s cancelled by the broker", tag)
+	}
+}
+
+func (c *Consumer) handle(ctx context.Context, d amqp.Delivery) {
+	if err := c.handler(ctx, d); err != nil {
+		c.log.Errorf("Error processing message from %s (attempt %d): %v", c.cfg.Queue, Attempts(d)+1, err)
+		if c.cfg.OnDeadLetter != nil && c.cfg.Retry.Exhausted(d, err) {
+			c.cfg.OnDeadLetter(ctx, d, err)
+		}
+		if err := c.publisher.Reject(ctx, c.cfg.Queue, d, err, c.cfg.Retry); err != nil {
+			c.log.Errorf("Error rejecting message: %v", err)
+		}
+		return
+	}
+
+	if err := d.Ack(false); err != nil {
+		c.log.Errorf("failed to ack message from %s: %v", c.cfg.Queue, err)
+	}
+}
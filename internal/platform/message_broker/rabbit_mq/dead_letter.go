@@ -0,0 +1,121 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeadLetter is a message parked in a dead-letter queue
+type DeadLetter struct {
+	MessageID      string
+	Attempts       int
+	LastError      string
+	DeadLetteredAt time.Time
+	Body           []byte
+}
+
+// PeekDeadLetters returns up to limit messages from the queue's dead-letter
+// queue without removing them. They are fetched on a throwaway channel whose
+// close puts them back.
+func (r *rabbitMQPublisher) PeekDeadLetters(queueName string, limit int) ([]DeadLetter, error) {
+	ch, err := r.openChannel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	defer ch.Close()
+
+	letters := []DeadLetter{}
+	for len(letters) < limit {
+		d, ok, err := ch.Get(DeadLetterQueue(queueName), false)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		letters = append(letters, toDeadLetter(d))
+	}
+
+	return letters, nil
+}
+
+// ReplayDeadLetters moves dead letters back to their queue with a fresh
+// attempt count. With messageIDs only those are replayed, otherwise the
+// oldest limit messages are. Returns how many were replayed.
+func (r *rabbitMQPublisher) ReplayDeadLetters(ctx context.Context, queueName string, messageIDs []string, limit int) (int, error) {
+	ch, err := r.openChannel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open a channel: %w", err)
+	}
+	// Skipped messages are still unacked and go back when the channel closes
+	defer ch.Close()
+
+	replayed := 0
+	remaining := -1
+
+	for replayed < limit && remaining != 0 {
+		d, ok, err := ch.Get(DeadLetterQueue(queueName), false)
+		if err != nil {
+			return replayed, err
+		}
+		if !ok {
+			break
+		}
+
+		// Only walk the messages that were queued when we started
+		if remaining < 0 {
+			remaining = int(d.MessageCount) + 1
+		}
+		remaining--
+
+		if len(messageIDs) > 0 && !slices.Contains(messageIDs, d.MessageId) {
+			continue
+		}
+
+		msg := amqp.Publishing{
+			Headers:      amqp.Table{},
+			ContentType:  d.ContentType,
+			Body:         d.Body,
+			DeliveryMode: amqp.Persistent,
+			MessageId:    d.MessageId,
+			Timestamp:    d.Timestamp,
+		}
+		for k, v := range d.Headers {
+			switch k {
+			case HeaderAttempts, HeaderLastError, HeaderDeadLetteredAt, HeaderOriginalQueue:
+			default:
+				msg.Headers[k] = v
+			}
+		}
+
+		if err := r.publishConfirmed(ctx, queueName, msg); err != nil {
+			return replayed, fmt.Errorf("failed to replay message %s: %w", d.MessageId, err)
+		}
+
+		if err := d.Ack(false); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+func toDeadLetter(d amqp.Delivery) DeadLetter {
+	letter := DeadLetter{
+		MessageID: d.MessageId,
+		Attempts:  Attempts(d),
+		Body:      d.Body,
+	}
+
+	letter.LastError, _ = d.Headers[HeaderLastError].(string)
+	if at, ok := d.Headers[HeaderDeadLetteredAt].(string); ok {
+		letter.DeadLetteredAt, _ = time.Parse(time.RFC3339, at)
+	}
+
+	return letter
+}
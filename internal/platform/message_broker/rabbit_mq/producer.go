@@ -4,51 +4,127 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type Publisher interface {
-	GetConnection() *amqp.Connection
 	CreateQueue(name string) error
 	Publish(ctx context.Context, queueName string, payload interface{}) error
+	PublishConfirmed(ctx context.Context, queueName string, messageID string, body []byte) error
+	Reject(ctx context.Context, queueName string, d amqp.Delivery, cause error, policy RetryPolicy) error
+	PeekDeadLetters(queueName string, limit int) ([]DeadLetter, error)
+	ReplayDeadLetters(ctx context.Context, queueName string, messageIDs []string, limit int) (int, error)
 	Close()
 }
 
+// rabbitMQPublisher redials lazily, the first publish after the broker
+// dropped the connection opens a new one
 type rabbitMQPublisher struct {
+	url string
+
+	// mu guards the connection and both channels
+	mu   sync.Mutex
 	conn *amqp.Connection
 	ch   *amqp.Channel
+	// confirmCh is a separate channel in confirm mode, opened on first use
+	confirmCh *amqp.Channel
 }
 
 func NewRabbitMQPublisher(url string) (*rabbitMQPublisher, error) {
-	conn, err := amqp.Dial(url)
+	r := &rabbitMQPublisher{url: url}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.channel(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// connection returns the live connection, dialing a new one when needed.
+// The caller must hold mu.
+func (r *rabbitMQPublisher) connection() (*amqp.Connection, error) {
+	if r.conn != nil && !r.conn.IsClosed() {
+		return r.conn, nil
+	}
+
+	conn, err := amqp.Dial(r.url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
+	r.conn = conn
+	r.ch = nil
+	r.confirmCh = nil
+	return conn, nil
+}
+
+// channel returns the plain publishing channel. The caller must hold mu.
+func (r *rabbitMQPublisher) channel() (*amqp.Channel, error) {
+	conn, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.ch != nil && !r.ch.IsClosed() {
+		return r.ch, nil
+	}
+
 	ch, err := conn.Channel()
 	if err != nil {
 		return nil, fmt.Errorf("failed to open a channel: %w", err)
 	}
 
-	return &rabbitMQPublisher{conn: conn, ch: ch}, nil
+	r.ch = ch
+	return ch, nil
 }
 
-func (r *rabbitMQPublisher) GetConnection() *amqp.Connection {
-	return r.conn
+// openChannel opens a new channel the caller owns and has to close
+func (r *rabbitMQPublisher) openChannel() (*amqp.Channel, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conn, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	return ch, nil
 }
 
+// CreateQueue declares a durable queue together with its dead-letter queue
 func (r *rabbitMQPublisher) CreateQueue(name string) error {
-	_, err := r.ch.QueueDeclare(
-		name,  // name of the queue
-		true,  // durable
-		false, // delete when unused
-		false, // exclusive
-		false, // no-wait
-		nil,   // arguments
-	)
-	return err
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch, err := r.channel()
+	if err != nil {
+		return err
+	}
+
+	for _, queue := range []string{name, DeadLetterQueue(name)} {
+		if _, err := ch.QueueDeclare(
+			queue, // name of the queue
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			nil,   // arguments
+		); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *rabbitMQPublisher) Publish(ctx context.Context, queueName string, payload interface{}) error {
@@ -57,7 +133,15 @@ func (r *rabbitMQPublisher) Publish(ctx context.Context, queueName string, paylo
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	return r.ch.PublishWithContext(ctx,
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch, err := r.channel()
+	if err != nil {
+		return err
+	}
+
+	return ch.PublishWithContext(ctx,
 		"",        // exchange
 		queueName, // routing key
 		false,     // mandatory
@@ -71,7 +155,83 @@ func (r *rabbitMQPublisher) Publish(ctx context.Context, queueName string, paylo
 	)
 }
 
+// PublishConfirmed publishes an already encoded JSON body and waits until the
+// broker confirms it has taken the message
+func (r *rabbitMQPublisher) PublishConfirmed(ctx context.Context, queueName string, messageID string, body []byte) error {
+	return r.publishConfirmed(ctx, queueName, amqp.Publishing{
+		ContentType:  "application/json",
+		Body:         body,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    messageID,
+		Timestamp:    time.Now(),
+	})
+}
+
+// publishConfirmed holds mu only while publishing. Confirms are tracked per
+// message, so concurrent callers wait for their broker ack side by side.
+func (r *rabbitMQPublisher) publishConfirmed(ctx context.Context, queueName string, msg amqp.Publishing) error {
+	r.mu.Lock()
+	ch, err := r.confirmChannel()
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx,
+		"",        // exchange
+		queueName, // routing key
+		false,     // mandatory
+		false,     // immediate
+		msg,
+	)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !acked {
+		return fmt.Errorf("broker nacked message %s", msg.MessageId)
+	}
+
+	return nil
+}
+
+// confirmChannel returns the confirm mode channel, reopening it after the
+// broker closed it. The caller must hold mu.
+func (r *rabbitMQPublisher) confirmChannel() (*amqp.Channel, error) {
+	conn, err := r.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	if r.confirmCh != nil && !r.confirmCh.IsClosed() {
+		return r.confirmCh, nil
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a channel: %w", err)
+	}
+
+	if err := ch.Confirm(false); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	r.confirmCh = ch
+	return ch, nil
+}
+
 func (r *rabbitMQPublisher) Close() {
-	r.ch.Close()
-	r.conn.Close()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn != nil {
+		r.conn.Close()
+	}
 }
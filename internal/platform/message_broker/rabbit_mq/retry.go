@@ -0,0 +1,167 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	// HeaderAttempts counts how many times a message has failed so far
+	HeaderAttempts = "x-attempts"
+	// HeaderLastError is the error of the latest failed attempt
+	HeaderLastError = "x-last-error"
+	// HeaderOriginalQueue is the queue a retried or dead-lettered message belongs to
+	HeaderOriginalQueue = "x-original-queue"
+	// HeaderDeadLetteredAt is when the message was parked in the dead-letter queue
+	HeaderDeadLetteredAt = "x-dead-lettered-at"
+
+	defaultMaxAttempts   = 5
+	defaultRetryDelay    = 5 * time.Second
+	defaultMaxRetryDelay = 5 * time.Minute
+)
+
+// ErrPermanent marks failures that retrying can't fix, such as a malformed
+// body. Wrap it and the message goes straight to the dead-letter queue.
+var ErrPermanent = errors.New("permanent failure")
+
+// RetryPolicy decides how often and how late a failed message is retried
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration // delay before the first retry, doubled for each one after
+	MaxDelay    time.Duration
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = defaultRetryDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = defaultMaxRetryDelay
+	}
+	return p
+}
+
+// Delay is how long to wait before retrying after the given failed attempt
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	p = p.withDefaults()
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > 16 {
+		return p.MaxDelay
+	}
+	return min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+}
+
+// Exhausted reports whether a delivery that failed with cause goes to the
+// dead-letter queue rather than back for another attempt
+func (p RetryPolicy) Exhausted(d amqp.Delivery, cause error) bool {
+	p = p.withDefaults()
+	return errors.Is(cause, ErrPermanent) || Attempts(d)+1 >= p.MaxAttempts
+}
+
+func DeadLetterQueue(name string) string {
+	return name + ".dlq"
+}
+
+// retryQueue holds messages for one delay. Its TTL dead-letters them back to
+// the original queue once they have waited long enough.
+func retryQueue(name string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", name, delay.Milliseconds())
+}
+
+// Attempts reads how many times the delivery has failed before
+func Attempts(d amqp.Delivery) int {
+	switch v := d.Headers[HeaderAttempts].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// Reject settles a delivery that failed with cause. It is retried through a
+// delay queue, or parked in the dead-letter queue once the policy's attempts
+// are used up or the error is permanent. The delivery is acked only after
+// its copy is confirmed, if that fails it is requeued as is.
+func (r *rabbitMQPublisher) Reject(ctx context.Context, queueName string, d amqp.Delivery, cause error, policy RetryPolicy) error {
+	msg := rejectedCopy(queueName, d, cause)
+
+	var err error
+	if policy.Exhausted(d, cause) {
+		msg.Headers[HeaderDeadLetteredAt] = time.Now().UTC().Format(time.RFC3339)
+		err = r.publishConfirmed(ctx, DeadLetterQueue(queueName), msg)
+	} else {
+		err = r.publishRetry(ctx, queueName, policy.Delay(Attempts(d)+1), msg)
+	}
+
+	if err != nil {
+		d.Nack(false, true)
+		return fmt.Errorf("failed to reject message: %w", err)
+	}
+
+	return d.Ack(false)
+}
+
+// rejectedCopy is the message republished for a failed delivery, with its
+// headers kept and the failure counted
+func rejectedCopy(queueName string, d amqp.Delivery, cause error) amqp.Publishing {
+	msg := amqp.Publishing{
+		Headers:      amqp.Table{},
+		ContentType:  d.ContentType,
+		Body:         d.Body,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Timestamp:    d.Timestamp,
+	}
+	for k, v := range d.Headers {
+		msg.Headers[k] = v
+	}
+	msg.Headers[HeaderAttempts] = int32(Attempts(d) + 1)
+	msg.Headers[HeaderLastError] = cause.Error()
+	msg.Headers[HeaderOriginalQueue] = queueName
+	if msg.MessageId == "" {
+		msg.MessageId = uuid.NewString()
+	}
+
+	return msg
+}
+
+func (r *rabbitMQPublisher) publishRetry(ctx context.Context, queueName string, delay time.Duration, msg amqp.Publishing) error {
+	name := retryQueue(queueName, delay)
+
+	r.mu.Lock()
+	ch, err := r.confirmChannel()
+	if err == nil {
+		_, err = ch.QueueDeclare(
+			name,  // name of the queue
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			amqp.Table{
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			},
+		)
+	}
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to declare retry queue %s: %w", name, err)
+	}
+
+	return r.publishConfirmed(ctx, name, msg)
+}
@@ -0,0 +1,126 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func deliveryWithAttempts(attempts interface{}) amqp.Delivery {
+	d := amqp.Delivery{Headers: amqp.Table{}}
+	if attempts != nil {
+		d.Headers[HeaderAttempts] = attempts
+	}
+	return d
+}
+
+func TestAttemptsReadsEveryIntegerType(t *testing.T) {
+	tests := []struct {
+		header interface{}
+		want   int
+	}{
+		{header: nil, want: 0},
+		{header: int32(3), want: 3},
+		{header: int64(4), want: 4},
+		{header: 5, want: 5},
+		{header: "6", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := Attempts(deliveryWithAttempts(tt.header)); got != tt.want {
+			t.Errorf("Attempts(%#v) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestRejectedCopyCountsTheFailure(t *testing.T) {
+	d := amqp.Delivery{
+		Headers:     amqp.Table{HeaderAttempts: int32(2), "x-custom": "kept"},
+		ContentType: "application/json",
+		Body:        []byte(`{"booking_id":"WT-TEST"}`),
+		MessageId:   "message-1",
+	}
+
+	msg := rejectedCopy("tickets", d, errors.New("render failed"))
+
+	if msg.Headers[HeaderAttempts] != int32(3) {
+		t.Fatalf("expected attempt 3, got %#v", msg.Headers[HeaderAttempts])
+	}
+	if msg.Headers[HeaderLastError] != "render failed" || msg.Headers[HeaderOriginalQueue] != "tickets" {
+		t.Fatalf("expected the error and queue recorded, got %v", msg.Headers)
+	}
+	if msg.Headers["x-custom"] != "kept" || msg.MessageId != "message-1" || string(msg.Body) != string(d.Body) {
+		t.Fatalf("expected the original message to be kept, got %+v", msg)
+	}
+	if msg.DeliveryMode != amqp.Persistent {
+		t.Fatalf("expected a persistent copy")
+	}
+
+	// The delivery itself is left untouched
+	if d.Headers[HeaderAttempts] != int32(2) {
+		t.Fatalf("rejectedCopy modified the delivery headers")
+	}
+
+	// Messages without an ID get one so the dead-letter API can address them
+	if msg := rejectedCopy("tickets", amqp.Delivery{}, errors.New("boom")); msg.MessageId == "" {
+		t.Fatalf("expected a message ID to be assigned")
+	}
+}
+
+func TestExhaustedRoutesToDeadLetterQueue(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3}
+	transient := errors.New("minio unavailable")
+
+	tests := []struct {
+		name     string
+		attempts int
+		cause    error
+		want     bool
+	}{
+		{name: "first failure", attempts: 0, cause: transient, want: false},
+		{name: "second failure", attempts: 1, cause: transient, want: false},
+		{name: "last attempt used", attempts: 2, cause: transient, want: true},
+		{name: "permanent on first failure", attempts: 0, cause: fmt.Errorf("%w: malformed body", ErrPermanent), want: true},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Exhausted(deliveryWithAttempts(int32(tt.attempts)), tt.cause); got != tt.want {
+			t.Errorf("%s: Exhausted = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if got := (RetryPolicy{}).Exhausted(deliveryWithAttempts(int32(defaultMaxAttempts-1)), transient); !got {
+		t.Errorf("expected the default policy to give up after %d attempts", defaultMaxAttempts)
+	}
+}
+
+func TestRetryDelayAndQueueNames(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 100, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := policy.Delay(tt.attempt); got != tt.want {
+			t.Errorf("Delay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+
+	if got := retryQueue("tickets", 2*time.Second); got != "tickets.retry.2000ms" {
+		t.Errorf("unexpected retry queue %s", got)
+	}
+	if got := DeadLetterQueue("tickets"); got != "tickets.dlq" {
+		t.Errorf("unexpected dead-letter queue %s", got)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/responses"
+	"go-war-ticket-service/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const AdmissionTokenHeader = "X-Admission-Token"
+
+// AdmissionCheck resolves a waiting room admission token to the event it
+// admits the user to. It returns domain.ErrAdmissionRequired when the token
+// is unknown, expired, still queued or belongs to someone else.
+type AdmissionCheck func(ctx context.Context, userID uuid.UUID, token string) (uuid.UUID, error)
+
+// RequireAdmission only lets through users the waiting room has admitted.
+// The admitted event is stored in the context so handlers can make sure the
+// request is for that event. It must run after AuthRequired.
+func RequireAdmission(check AdmissionCheck, log *zap.SugaredLogger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get(AdmissionTokenHeader)
+		if token == "" {
+			return responses.Error(c, fiber.StatusForbidden, domain.ErrAdmissionRequired.Error())
+		}
+
+		userID, ok := c.Locals(utils.UserID).(uuid.UUID)
+		if !ok {
+			return responses.Error(c, fiber.StatusUnauthorized, domain.ErrUnauthorized.Error())
+		}
+
+		eventID, err := check(c.Context(), userID, token)
+		if err != nil {
+			if errors.Is(err, domain.ErrAdmissionRequired) {
+				return responses.Error(c, fiber.StatusForbidden, err.Error())
+			}
+			log.Errorf("failed to check admission token: %v", err)
+			return responses.Error(c, fiber.StatusInternalServerError, domain.ErrInternal.Error())
+		}
+
+		c.Locals(utils.AdmittedEventID, eventID)
+
+		return c.Next()
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"go-war-ticket-service/internal/domain"
 	"go-war-ticket-service/internal/platform/responses"
 	"go-war-ticket-service/internal/utils"
@@ -9,11 +10,13 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 )
 
 // AuthRequired is a middleware that checks for a valid JWT token in the Authorization header
-func AuthRequired(secret string, log *zap.SugaredLogger) fiber.Handler {
+// and rejects tokens that were revoked on logout
+func AuthRequired(secret string, cache *redis.Client, log *zap.SugaredLogger) fiber.Handler {
 	return jwtware.New(jwtware.Config{
 		// Use jwtware to validate the token
 		SigningKey:  jwtware.SigningKey{Key: []byte(secret)},
@@ -55,8 +58,34 @@ func AuthRequired(secret string, log *zap.SugaredLogger) fiber.Handler {
 				return responses.Error(c, fiber.StatusUnauthorized, domain.ErrUnauthorized.Error())
 			}
 
-			// Set user ID to context
+			// Revoked tokens stay on the denylist until they would have expired
+			if jti, ok := claims["jti"].(string); ok && jti != "" {
+				revoked, err := cache.Exists(c.Context(), fmt.Sprintf(utils.AccessTokenDenylistKey, jti)).Result()
+				if err != nil {
+					log.Errorf("failed to check access token denylist: %v", err)
+					return responses.Error(c, fiber.StatusInternalServerError, domain.ErrInternal.Error())
+				}
+
+				if revoked > 0 {
+					return responses.Error(c, fiber.StatusUnauthorized, domain.ErrUnauthorized.Error())
+				}
+
+				c.Locals(utils.TokenID, jti)
+			}
+
+			if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+				c.Locals(utils.TokenExpiresAt, exp.Time)
+			}
+
+			// Tokens issued before roles existed belong to customers
+			role := domain.RoleCustomer
+			if roleStr, ok := claims["role"].(string); ok && roleStr != "" {
+				role = domain.UserRole(roleStr)
+			}
+
+			// Set user ID and role to context
 			c.Locals(utils.UserID, userID)
+			c.Locals(utils.UserRole, role)
 
 			return c.Next()
 		},
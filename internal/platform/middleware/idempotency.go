@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/responses"
+	"go-war-ticket-service/internal/utils"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	idempotencyMaxKeyLength   = 255
+	idempotencyLockTTL        = time.Minute            // how long an in-flight request holds the key between heartbeats
+	idempotencyHeartbeat      = idempotencyLockTTL / 3 // how often a running handler extends its lock
+	defaultIdempotencyTTL     = 24 * time.Hour
+)
+
+type idempotencyState string
+
+const (
+	idempotencyInProgress idempotencyState = "in_progress"
+	idempotencyCompleted  idempotencyState = "completed"
+)
+
+// extendLockScript pushes back the expiry of a lock, as long as it is still
+// the one this request took
+var extendLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// idempotencyRecord is what is kept in Redis for every key
+type idempotencyRecord struct {
+	State       idempotencyState `json:"state"`
+	Fingerprint string           `json:"fingerprint"`
+	StatusCode  int              `json:"status_code,omitempty"`
+	ContentType string           `json:"content_type,omitempty"`
+	Body        []byte           `json:"body,omitempty"`
+}
+
+// Idempotency makes retries of a request carrying an Idempotency-Key header
+// safe. The first response is stored and replayed for later requests with
+// the same key, and reusing a key for a different payload is rejected. Keys
+// are scoped per user, so it must run after AuthRequired. Requests without
+// the header pass through untouched.
+func Idempotency(cache *redis.Client, ttl time.Duration, log *zap.SugaredLogger) fiber.Handler {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+
+		if len(key) > idempotencyMaxKeyLength {
+			return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidIdempotencyKey.Error())
+		}
+
+		userID, _ := c.Locals(utils.UserID).(uuid.UUID)
+		redisKey := fmt.Sprintf(utils.IdempotencyKey, userID.String(), hashParts(c.Method(), c.Path(), key))
+		fingerprint := hashParts(c.Method(), c.Path(), string(c.Body()))
+
+		lock, _ := json.Marshal(idempotencyRecord{State: idempotencyInProgress, Fingerprint: fingerprint})
+		acquired, err := cache.SetNX(c.Context(), redisKey, lock, idempotencyLockTTL).Result()
+		if err != nil {
+			log.Errorf("failed to acquire idempotency key: %v", err)
+			return responses.Error(c, fiber.StatusInternalServerError, domain.ErrInternal.Error())
+		}
+
+		if !acquired {
+			return replay(c, cache, redisKey, fingerprint, log)
+		}
+
+		// The lock is short so a crashed instance frees the key quickly, a
+		// slow handler keeps it alive instead
+		stopHeartbeat := keepLock(cache, redisKey, lock, idempotencyHeartbeat, log)
+		err = c.Next()
+		stopHeartbeat()
+
+		if err != nil {
+			cache.Del(c.Context(), redisKey)
+			return err
+		}
+
+		// Server errors are worth retrying, so they are not remembered
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			cache.Del(c.Context(), redisKey)
+			return nil
+		}
+
+		record, _ := json.Marshal(idempotencyRecord{
+			State:       idempotencyCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        append([]byte(nil), c.Response().Body()...),
+		})
+		if err := cache.Set(c.Context(), redisKey, record, ttl).Err(); err != nil {
+			log.Errorf("failed to store idempotent response: %v", err)
+		}
+
+		return nil
+	}
+}
+
+// keepLock extends the lock at redisKey every interval until the returned
+// function is called. That function only returns once the heartbeat has
+// stopped, so it can't shorten the TTL of the record stored afterwards.
+func keepLock(cache *redis.Client, redisKey string, lock []byte, interval time.Duration, log *zap.SugaredLogger) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				err := extendLockScript.Run(ctx, cache, []string{redisKey}, lock, idempotencyLockTTL.Milliseconds()).Err()
+				cancel()
+				if err != nil {
+					log.Warnf("failed to extend idempotency lock: %v", err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func replay(c *fiber.Ctx, cache *redis.Client, redisKey, fingerprint string, log *zap.SugaredLogger) error {
+	raw, err := cache.Get(c.Context(), redisKey).Bytes()
+	if err == redis.Nil {
+		// The first request failed and released the key in the meantime
+		return responses.Error(c, fiber.StatusConflict, domain.ErrIdempotencyRequestInProgress.Error())
+	}
+	if err != nil {
+		log.Errorf("failed to read idempotency key: %v", err)
+		return responses.Error(c, fiber.StatusInternalServerError, domain.ErrInternal.Error())
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		log.Errorf("corrupt idempotency record %s: %v", redisKey, err)
+		return responses.Error(c, fiber.StatusInternalServerError, domain.ErrInternal.Error())
+	}
+
+	if record.Fingerprint != fingerprint {
+		return responses.Error(c, fiber.StatusUnprocessableEntity, domain.ErrIdempotencyKeyMismatch.Error())
+	}
+
+	if record.State != idempotencyCompleted {
+		return responses.Error(c, fiber.StatusConflict, domain.ErrIdempotencyRequestInProgress.Error())
+	}
+
+	c.Set(idempotencyReplayedHeader, "true")
+	if record.ContentType != "" {
+		c.Set(fiber.HeaderContentType, record.ContentType)
+	}
+	return c.Status(record.StatusCode).Send(record.Body)
+}
+
+func hashParts(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
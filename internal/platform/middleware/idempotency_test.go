@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"go-war-ticket-service/internal/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type idempotencyTest struct {
+	server *miniredis.Miniredis
+	cache  *redis.Client
+	app    *fiber.App
+	calls  atomic.Int32
+	status int
+	block  chan struct{} // when set, the handler waits for it to close
+}
+
+func newIdempotencyTest(t *testing.T) *idempotencyTest {
+	t.Helper()
+
+	tt := &idempotencyTest{status: fiber.StatusCreated}
+	tt.server = miniredis.RunT(t)
+	tt.cache = redis.NewClient(&redis.Options{Addr: tt.server.Addr()})
+	t.Cleanup(func() { tt.cache.Close() })
+
+	userID := uuid.New()
+	tt.app = fiber.New()
+	tt.app.Use(func(c *fiber.Ctx) error {
+		c.Locals(utils.UserID, userID)
+		return c.Next()
+	})
+	tt.app.Use(Idempotency(tt.cache, time.Hour, zap.NewNop().Sugar()))
+	tt.app.Post("/orders", func(c *fiber.Ctx) error {
+		n := tt.calls.Add(1)
+		if tt.block != nil {
+			<-tt.block
+		}
+		return c.Status(tt.status).JSON(fiber.Map{"call": n})
+	})
+
+	return tt
+}
+
+func (tt *idempotencyTest) post(t *testing.T, key, body string) (*http.Response, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(fiber.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	res, err := tt.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return res, string(data)
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	tt := newIdempotencyTest(t)
+
+	first, firstBody := tt.post(t, "key-1", `{"tier":"vip"}`)
+	second, secondBody := tt.post(t, "key-1", `{"tier":"vip"}`)
+
+	if tt.calls.Load() != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", tt.calls.Load())
+	}
+	if second.StatusCode != first.StatusCode || secondBody != firstBody {
+		t.Fatalf("expected replay of %d %s, got %d %s", first.StatusCode, firstBody, second.StatusCode, secondBody)
+	}
+	if second.Header.Get(idempotencyReplayedHeader) != "true" {
+		t.Fatalf("expected the replay to be flagged")
+	}
+
+	// Another key is a new request
+	tt.post(t, "key-2", `{"tier":"vip"}`)
+	if tt.calls.Load() != 2 {
+		t.Fatalf("expected a new key to reach the handler")
+	}
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	tt := newIdempotencyTest(t)
+
+	tt.post(t, "key-1", `{"tier":"vip"}`)
+	res, _ := tt.post(t, "key-1", `{"tier":"regular"}`)
+
+	if res.StatusCode != fiber.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a reused key, got %d", res.StatusCode)
+	}
+	if tt.calls.Load() != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", tt.calls.Load())
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRetry(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	tt.block = make(chan struct{})
+
+	done := make(chan int)
+	go func() {
+		res, _ := tt.post(t, "key-1", `{"tier":"vip"}`)
+		done <- res.StatusCode
+	}()
+
+	// Wait for the first request to reach the handler
+	deadline := time.Now().Add(5 * time.Second)
+	for tt.calls.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("first request never reached the handler")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	res, _ := tt.post(t, "key-1", `{"tier":"vip"}`)
+	if res.StatusCode != fiber.StatusConflict {
+		t.Fatalf("expected 409 while the first request is in flight, got %d", res.StatusCode)
+	}
+
+	close(tt.block)
+	if status := <-done; status != fiber.StatusCreated {
+		t.Fatalf("expected the first request to finish with 201, got %d", status)
+	}
+}
+
+func TestIdempotencyForgetsServerErrors(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	tt.status = fiber.StatusServiceUnavailable
+
+	tt.post(t, "key-1", `{"tier":"vip"}`)
+	tt.status = fiber.StatusCreated
+	res, body := tt.post(t, "key-1", `{"tier":"vip"}`)
+
+	if res.StatusCode != fiber.StatusCreated || tt.calls.Load() != 2 {
+		t.Fatalf("expected the retry to run the handler again, got %d %s after %d calls", res.StatusCode, body, tt.calls.Load())
+	}
+}
+
+func TestKeepLockExtendsOnlyItsOwnLock(t *testing.T) {
+	tt := newIdempotencyTest(t)
+	log := zap.NewNop().Sugar()
+
+	lock := []byte(`{"state":"in_progress"}`)
+	tt.server.Set("held", string(lock))
+	tt.server.SetTTL("held", time.Second)
+	tt.server.Set("taken", "someone else")
+	tt.server.SetTTL("taken", time.Second)
+
+	stopHeld := keepLock(tt.cache, "held", lock, 5*time.Millisecond, log)
+	stopTaken := keepLock(tt.cache, "taken", lock, 5*time.Millisecond, log)
+	time.Sleep(50 * time.Millisecond)
+	stopHeld()
+	stopTaken()
+
+	if ttl := tt.server.TTL("held"); ttl != idempotencyLockTTL {
+		t.Fatalf("expected the lock to be extended to %s, got %s", idempotencyLockTTL, ttl)
+	}
+	if ttl := tt.server.TTL("taken"); ttl != time.Second {
+		t.Fatalf("expected another request's lock to be left alone, got %s", ttl)
+	}
+}
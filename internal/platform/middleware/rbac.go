@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+	"go-war-ticket-service/internal/platform/responses"
+	"go-war-ticket-service/internal/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// EventOwnerLookup resolves the organiser that owns an event. It returns
+// domain.ErrEventNotFound when the event doesn't exist.
+type EventOwnerLookup func(ctx context.Context, eventID uuid.UUID) (uuid.UUID, error)
+
+// RequireRoles only lets through users holding one of the given roles.
+// It must run after AuthRequired.
+func RequireRoles(roles ...domain.UserRole) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := c.Locals(utils.UserRole).(domain.UserRole)
+		if !ok || !role.OneOf(roles...) {
+			return responses.Error(c, fiber.StatusForbidden, domain.ErrForbidden.Error())
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireEventOwner only lets the organiser of the :event_id route param
+// through. Admins can manage every event. It must run after AuthRequired.
+func RequireEventOwner(lookup EventOwnerLookup, log *zap.SugaredLogger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals(utils.UserRole).(domain.UserRole)
+		if role == domain.RoleAdmin {
+			return c.Next()
+		}
+
+		userID, ok := c.Locals(utils.UserID).(uuid.UUID)
+		if !ok || role != domain.RoleOrganiser {
+			return responses.Error(c, fiber.StatusForbidden, domain.ErrForbidden.Error())
+		}
+
+		eventID, err := uuid.Parse(c.Params("event_id"))
+		if err != nil {
+			return responses.Error(c, fiber.StatusBadRequest, domain.ErrInvalidID.Error())
+		}
+
+		ownerID, err := lookup(c.Context(), eventID)
+		if err != nil {
+			if err == domain.ErrEventNotFound {
+				return responses.Error(c, fiber.StatusNotFound, err.Error())
+			}
+			log.Errorf("failed to look up owner of event %s: %v", eventID, err)
+			return responses.Error(c, fiber.StatusInternalServerError, domain.ErrInternal.Error())
+		}
+
+		if ownerID != userID {
+			log.Warnf("user %s tried to manage event %s owned by %s", userID, eventID, ownerID)
+			return responses.Error(c, fiber.StatusForbidden, domain.ErrForbidden.Error())
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultInterval  = time.Second
+	defaultBatchSize = 100
+	defaultRetention = 7 * 24 * time.Hour
+	publishTimeout   = 5 * time.Second
+	retryBackoff     = time.Second
+	maxRetryBackoff  = 5 * time.Minute
+	cleanupInterval  = time.Hour
+)
+
+// Publisher is the part of the message broker the relay needs
+type Publisher interface {
+	PublishConfirmed(ctx context.Context, queueName string, messageID string, body []byte) error
+}
+
+type Config struct {
+	Interval  time.Duration // how often pending messages are looked for
+	BatchSize int           // messages claimed per transaction
+	Retention time.Duration // how long sent messages are kept
+}
+
+// Relay publishes pending outbox messages and marks them sent once the
+// broker confirms them. Rows are claimed with a lease in a short transaction,
+// so every instance can run a relay without publishing the same message
+// twice at once, and no row lock is held while talking to the broker.
+// Delivery is at least once, consumers have to cope with duplicates.
+type Relay struct {
+	store       store
+	publisher   Publisher
+	cfg         Config
+	lease       time.Duration
+	log         *zap.SugaredLogger
+	lastCleanup time.Time
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, cfg Config, log *zap.SugaredLogger) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultBatchSize
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaultRetention
+	}
+
+	return &Relay{
+		store:     &dbStore{db: db},
+		publisher: publisher,
+		cfg:       cfg,
+		// Long enough to publish a whole batch even if every message takes
+		// the full timeout, so a lease never runs out mid batch
+		lease: time.Duration(cfg.BatchSize) * publishTimeout,
+		log:   log.Named("OutboxRelay"),
+	}
+}
+
+// Start relays pending messages every interval until ctx is cancelled
+func (r *Relay) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while full batches come back, there's a backlog
+		for ctx.Err() == nil {
+			claimed, err := r.relayBatch(ctx)
+			if err != nil {
+				r.log.Errorf("failed to relay outbox messages: %v", err)
+				break
+			}
+			if claimed < r.cfg.BatchSize {
+				break
+			}
+		}
+
+		if time.Since(r.lastCleanup) >= cleanupInterval {
+			r.cleanup(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publishes one batch of due messages and returns how many it
+// claimed. Each message is marked sent as soon as the broker confirms it. It
+// stops at the first failure, the broker is most likely down and the rest of
+// the batch would only fail the same way. Their lease runs out and they are
+// claimed again later.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	messages, err := r.store.claim(ctx, r.cfg.BatchSize, r.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, msg := range messages {
+		publishCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		err := r.publisher.PublishConfirmed(publishCtx, msg.Queue, msg.ID.String(), []byte(msg.Payload))
+		cancel()
+
+		if err != nil {
+			r.log.Warnf("failed to publish outbox message %s to %s (attempt %d): %v", msg.ID, msg.Queue, msg.Attempts+1, err)
+			if err := r.store.markFailed(ctx, msg, err); err != nil {
+				r.log.Errorf("failed to reschedule outbox message %s: %v", msg.ID, err)
+			}
+			return 0, nil
+		}
+
+		// Left PENDING, the message is published again once its lease runs out
+		if err := r.store.markSent(ctx, msg); err != nil {
+			r.log.Errorf("failed to mark outbox message %s as sent: %v", msg.ID, err)
+		}
+	}
+
+	return len(messages), nil
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	r.lastCleanup = time.Now()
+
+	removed, err := r.store.cleanup(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		r.log.Errorf("failed to clean up sent outbox messages: %v", err)
+		return
+	}
+
+	if removed > 0 {
+		r.log.Infof("Removed %d sent outbox messages", removed)
+	}
+}
@@ -0,0 +1,178 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type memoryStore struct {
+	pending    []domain.OutboxMessage
+	lease      time.Duration
+	sent       []uuid.UUID
+	failed     []uuid.UUID
+	markSentFn func(msg domain.OutboxMessage) error
+}
+
+func (s *memoryStore) claim(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	s.lease = lease
+	claimed := s.pending[:min(limit, len(s.pending))]
+	s.pending = s.pending[len(claimed):]
+	return claimed, nil
+}
+
+func (s *memoryStore) markSent(ctx context.Context, msg domain.OutboxMessage) error {
+	if s.markSentFn != nil {
+		if err := s.markSentFn(msg); err != nil {
+			return err
+		}
+	}
+	s.sent = append(s.sent, msg.ID)
+	return nil
+}
+
+func (s *memoryStore) markFailed(ctx context.Context, msg domain.OutboxMessage, cause error) error {
+	s.failed = append(s.failed, msg.ID)
+	return nil
+}
+
+func (s *memoryStore) cleanup(ctx context.Context, sentBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+type recordingPublisher struct {
+	published []string
+	failOn    string // message ID whose publish fails
+}
+
+func (p *recordingPublisher) PublishConfirmed(ctx context.Context, queueName string, messageID string, body []byte) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("publish without a timeout")
+	}
+	if messageID == p.failOn {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, messageID)
+	return nil
+}
+
+func newTestRelay(store *memoryStore, publisher *recordingPublisher, batchSize int) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		cfg:       Config{BatchSize: batchSize},
+		lease:     time.Duration(batchSize) * publishTimeout,
+		log:       zap.NewNop().Sugar(),
+	}
+}
+
+func pendingMessages(t *testing.T, n int) []domain.OutboxMessage {
+	t.Helper()
+
+	messages := make([]domain.OutboxMessage, n)
+	for i := range messages {
+		msg, err := domain.NewOutboxMessage("test-queue", map[string]int{"n": i})
+		if err != nil {
+			t.Fatalf("new outbox message: %v", err)
+		}
+		msg.ID = uuid.New()
+		messages[i] = msg
+	}
+	return messages
+}
+
+func TestRelayBatchMarksEachMessageSent(t *testing.T) {
+	messages := pendingMessages(t, 5)
+	store := &memoryStore{pending: messages}
+	publisher := &recordingPublisher{}
+	relay := newTestRelay(store, publisher, 3)
+
+	claimed, err := relay.relayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("relay batch: %v", err)
+	}
+	if claimed != 3 {
+		t.Fatalf("expected a full batch of 3, got %d", claimed)
+	}
+	if len(store.sent) != 3 || len(publisher.published) != 3 {
+		t.Fatalf("expected 3 messages published and marked sent, got %d and %d", len(publisher.published), len(store.sent))
+	}
+	if store.lease != 3*publishTimeout {
+		t.Fatalf("expected a lease covering the whole batch, got %s", store.lease)
+	}
+}
+
+func TestRelayBatchStopsAtFirstFailure(t *testing.T) {
+	messages := pendingMessages(t, 3)
+	store := &memoryStore{pending: messages}
+	publisher := &recordingPublisher{failOn: messages[1].ID.String()}
+	relay := newTestRelay(store, publisher, 10)
+
+	claimed, err := relay.relayBatch(context.Background())
+	if err != nil {
+		t.Fatalf("relay batch: %v", err)
+	}
+	if claimed != 0 {
+		t.Fatalf("expected a failed batch not to be reported as full, got %d", claimed)
+	}
+
+	// The message published before the failure stays sent
+	if len(store.sent) != 1 || store.sent[0] != messages[0].ID {
+		t.Fatalf("expected only the first message marked sent, got %v", store.sent)
+	}
+	if len(store.failed) != 1 || store.failed[0] != messages[1].ID {
+		t.Fatalf("expected the second message rescheduled, got %v", store.failed)
+	}
+	if len(publisher.published) != 1 {
+		t.Fatalf("expected nothing published after the failure, got %v", publisher.published)
+	}
+}
+
+func TestRelayBatchKeepsGoingWhenMarkSentFails(t *testing.T) {
+	messages := pendingMessages(t, 3)
+	store := &memoryStore{
+		pending: messages,
+		markSentFn: func(msg domain.OutboxMessage) error {
+			if msg.ID == messages[0].ID {
+				return errors.New("database unavailable")
+			}
+			return nil
+		},
+	}
+	publisher := &recordingPublisher{}
+	relay := newTestRelay(store, publisher, 10)
+
+	if _, err := relay.relayBatch(context.Background()); err != nil {
+		t.Fatalf("relay batch: %v", err)
+	}
+	if len(publisher.published) != 3 {
+		t.Fatalf("expected every message published, got %d", len(publisher.published))
+	}
+	if len(store.sent) != 2 || len(store.failed) != 0 {
+		t.Fatalf("expected 2 messages marked sent and none failed, got %v and %v", store.sent, store.failed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: retryBackoff},
+		{attempts: 1, want: 2 * retryBackoff},
+		{attempts: 5, want: 32 * retryBackoff},
+		{attempts: 10, want: maxRetryBackoff},
+		{attempts: 64, want: maxRetryBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"context"
+	"go-war-ticket-service/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// store is where the relay claims and settles messages
+type store interface {
+	claim(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
+	markSent(ctx context.Context, msg domain.OutboxMessage) error
+	markFailed(ctx context.Context, msg domain.OutboxMessage, cause error) error
+	cleanup(ctx context.Context, sentBefore time.Time) (int64, error)
+}
+
+type dbStore struct {
+	db *gorm.DB
+}
+
+// claim takes up to limit due messages and pushes their available_at past
+// the lease, so no other relay picks them up while they are published. The
+// rows are only locked for this short transaction.
+func (s *dbStore) claim(ctx context.Context, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	var messages []domain.OutboxMessage
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND available_at <= ?", domain.OutboxPending, time.Now()).
+			Order("available_at ASC").
+			Limit(limit).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]interface{}, len(messages))
+		for i, msg := range messages {
+			ids[i] = msg.ID
+		}
+
+		return tx.Model(&domain.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Update("available_at", time.Now().Add(lease)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (s *dbStore) markSent(ctx context.Context, msg domain.OutboxMessage) error {
+	return s.db.WithContext(ctx).Model(&msg).Updates(map[string]interface{}{
+		"status":     domain.OutboxSent,
+		"attempts":   msg.Attempts + 1,
+		"sent_at":    time.Now(),
+		"last_error": "",
+	}).Error
+}
+
+// markFailed pushes the message back with exponential backoff
+func (s *dbStore) markFailed(ctx context.Context, msg domain.OutboxMessage, cause error) error {
+	return s.db.WithContext(ctx).Model(&msg).Updates(map[string]interface{}{
+		"attempts":     msg.Attempts + 1,
+		"available_at": time.Now().Add(retryDelay(msg.Attempts)),
+		"last_error":   cause.Error(),
+	}).Error
+}
+
+// retryDelay is how long a message waits after failing attempts times
+func retryDelay(attempts int) time.Duration {
+	if attempts >= 16 {
+		return maxRetryBackoff
+	}
+	return min(retryBackoff<<attempts, maxRetryBackoff)
+}
+
+func (s *dbStore) cleanup(ctx context.Context, sentBefore time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).Unscoped().
+		Where("status = ? AND sent_at < ?", domain.OutboxSent, sentBefore).
+		Delete(&domain.OutboxMessage{})
+
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,93 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-war-ticket-service/internal/domain"
+	"net/http"
+	"strings"
+)
+
+// FakeSignatureHeader carries the hex HMAC-SHA256 of the raw webhook body
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider is a local stand-in for a real gateway. Webhooks are still
+// authenticated, with an HMAC of the body keyed by a shared secret.
+type FakeProvider struct {
+	secret string
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{secret: secret}
+}
+
+func (f *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (f *FakeProvider) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	return &Charge{
+		Reference:   "fake-" + req.OrderID,
+		RedirectURL: "http://localhost/fake-payment/" + req.OrderID,
+	}, nil
+}
+
+// ParseWebhook expects {"booking_id": "...", "payment_status": "PAID",
+// "gross_amount": "150000.00"} signed in the X-Fake-Signature header
+func (f *FakeProvider) ParseWebhook(ctx context.Context, body []byte, headers http.Header) (*Notification, error) {
+	signature, err := hex.DecodeString(headers.Get(FakeSignatureHeader))
+	if err != nil || !hmac.Equal(signature, f.Sign(body)) {
+		return nil, domain.ErrInvalidPaymentSignature
+	}
+
+	var payload struct {
+		BookingID     string          `json:"booking_id"`
+		PaymentStatus string          `json:"payment_status"`
+		GrossAmount   json.RawMessage `json:"gross_amount"` // number or string
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, domain.ErrInvalidPaymentSignature
+	}
+
+	notification := &Notification{
+		OrderID:     payload.BookingID,
+		RawStatus:   payload.PaymentStatus,
+		Status:      StatusFailed,
+		GrossAmount: strings.Trim(string(payload.GrossAmount), `"`),
+	}
+
+	switch strings.ToUpper(payload.PaymentStatus) {
+	case "PAID", "SETTLEMENT":
+		notification.Status = StatusPaid
+	case "PENDING":
+		notification.Status = StatusPending
+	case "REFUNDED":
+		notification.Status = StatusRefunded
+	}
+
+	return notification, nil
+}
+
+// GetStatus has no remote state to query, so payments stay pending until a webhook arrives
+func (f *FakeProvider) GetStatus(ctx context.Context, orderID string) (*Notification, error) {
+	return &Notification{OrderID: orderID, Status: StatusPending}, nil
+}
+
+func (f *FakeProvider) Refund(ctx context.Context, req RefundRequest) error {
+	return nil
+}
+
+// ChargedAmount is the amount as is, the fake provider does no rounding
+func (f *FakeProvider) ChargedAmount(amount domain.Money) domain.Money {
+	return amount
+}
+
+// Sign returns the HMAC a client must send to have a fake webhook accepted
+func (f *FakeProvider) Sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(f.secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
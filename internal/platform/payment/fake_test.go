@@ -0,0 +1,40 @@
+package payment
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"net/http"
+	"testing"
+)
+
+func TestFakeParseWebhookVerifiesSignature(t *testing.T) {
+	f := NewFakeProvider("webhook-secret")
+	body := []byte(`{"booking_id":"WT-TEST","payment_status":"paid","gross_amount":150000.5}`)
+
+	headers := http.Header{}
+	headers.Set(FakeSignatureHeader, hex.EncodeToString(f.Sign(body)))
+
+	notification, err := f.ParseWebhook(context.Background(), body, headers)
+	if err != nil {
+		t.Fatalf("valid webhook rejected: %v", err)
+	}
+	if notification.OrderID != "WT-TEST" || notification.Status != StatusPaid || notification.GrossAmount != "150000.5" {
+		t.Fatalf("unexpected notification %+v", notification)
+	}
+
+	tests := map[string]string{
+		"missing":     "",
+		"not hex":     "zz",
+		"another key": hex.EncodeToString(NewFakeProvider("other").Sign(body)),
+		"other body":  hex.EncodeToString(f.Sign([]byte(`{}`))),
+	}
+	for name, signature := range tests {
+		headers := http.Header{}
+		headers.Set(FakeSignatureHeader, signature)
+		if _, err := f.ParseWebhook(context.Background(), body, headers); !errors.Is(err, domain.ErrInvalidPaymentSignature) {
+			t.Errorf("%s signature: expected ErrInvalidPaymentSignature, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-war-ticket-service/internal/domain"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	midtransSnapSandboxURL      = "https://app.sandbox.midtrans.com/snap/v1/transactions"
+	midtransSnapProductionURL   = "https://app.midtrans.com/snap/v1/transactions"
+	midtransCoreSandboxURL      = "https://api.sandbox.midtrans.com/v2"
+	midtransCoreProductionURL   = "https://api.midtrans.com/v2"
+	midtransTransactionNotFound = "404"
+	midtransFraudStatusAccept   = "accept"
+	midtransTransactionCapture  = "capture"
+	midtransTransactionSettled  = "settlement"
+	midtransTransactionPending  = "pending"
+	midtransTransactionRefund   = "refund"
+	midtransRequestTimeout      = 10 * time.Second
+)
+
+// MidtransProvider talks to Midtrans Snap for charges and verifies its
+// notifications with the SHA512 signature_key scheme
+type MidtransProvider struct {
+	serverKey  string
+	snapURL    string
+	coreURL    string
+	httpClient *http.Client
+}
+
+func NewMidtransProvider(serverKey string, isProduction bool) *MidtransProvider {
+	provider := &MidtransProvider{
+		serverKey:  serverKey,
+		snapURL:    midtransSnapSandboxURL,
+		coreURL:    midtransCoreSandboxURL,
+		httpClient: &http.Client{Timeout: midtransRequestTimeout},
+	}
+
+	if isProduction {
+		provider.snapURL = midtransSnapProductionURL
+		provider.coreURL = midtransCoreProductionURL
+	}
+
+	return provider
+}
+
+type midtransNotification struct {
+	OrderID           string `json:"order_id"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+}
+
+func (m *MidtransProvider) Name() string {
+	return "midtrans"
+}
+
+func (m *MidtransProvider) CreateCharge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	// Midtrans rejects the charge unless item_details add up to gross_amount
+	items := []map[string]interface{}{
+		{
+			"id":       req.OrderID,
+			"name":     req.ItemName,
+			"price":    midtransAmount(req.UnitPrice),
+			"quantity": max(req.Quantity, 1),
+		},
+	}
+
+	if req.Fee > 0 {
+		items = append(items, map[string]interface{}{
+			"id":       req.OrderID + "-fee",
+			"name":     "Service fee",
+			"price":    midtransAmount(req.Fee),
+			"quantity": 1,
+		})
+	}
+
+	if req.Discount > 0 {
+		items = append(items, map[string]interface{}{
+			"id":       req.OrderID + "-discount",
+			"name":     "Discount",
+			"price":    -midtransAmount(req.Discount),
+			"quantity": 1,
+		})
+	}
+
+	body := map[string]interface{}{
+		"transaction_details": map[string]interface{}{
+			"order_id":     req.OrderID,
+			"gross_amount": midtransAmount(req.Amount),
+		},
+		"item_details": items,
+	}
+
+	if req.ExpiresIn > 0 {
+		body["expiry"] = map[string]interface{}{
+			"unit":     "minutes",
+			"duration": int(req.ExpiresIn.Minutes()),
+		}
+	}
+
+	var res struct {
+		Token         string   `json:"token"`
+		RedirectURL   string   `json:"redirect_url"`
+		ErrorMessages []string `json:"error_messages"`
+	}
+
+	status, err := m.do(ctx, http.MethodPost, m.snapURL, body, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if status != http.StatusCreated && status != http.StatusOK {
+		return nil, fmt.Errorf("midtrans charge failed with status %d: %v", status, res.ErrorMessages)
+	}
+
+	return &Charge{Reference: res.Token, RedirectURL: res.RedirectURL}, nil
+}
+
+// ParseWebhook verifies signature_key = SHA512(order_id + status_code + gross_amount + server_key)
+func (m *MidtransProvider) ParseWebhook(ctx context.Context, body []byte, headers http.Header) (*Notification, error) {
+	var payload midtransNotification
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, domain.ErrInvalidPaymentSignature
+	}
+
+	expected := m.signature(payload.OrderID, payload.StatusCode, payload.GrossAmount)
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(payload.SignatureKey)) != 1 {
+		return nil, domain.ErrInvalidPaymentSignature
+	}
+
+	return m.toNotification(payload), nil
+}
+
+func (m *MidtransProvider) GetStatus(ctx context.Context, orderID string) (*Notification, error) {
+	var payload midtransNotification
+
+	endpoint := fmt.Sprintf("%s/%s/status", m.coreURL, url.PathEscape(orderID))
+	if _, err := m.do(ctx, http.MethodGet, endpoint, nil, &payload); err != nil {
+		return nil, err
+	}
+
+	// The customer never opened the payment page
+	if payload.StatusCode == midtransTransactionNotFound {
+		return &Notification{OrderID: orderID, Status: StatusPending}, nil
+	}
+
+	return m.toNotification(payload), nil
+}
+
+// Refund requests a full refund of a settled transaction
+func (m *MidtransProvider) Refund(ctx context.Context, req RefundRequest) error {
+	body := map[string]interface{}{
+		"refund_key": req.OrderID + "-refund",
+		"amount":     midtransAmount(req.Amount),
+		"reason":     req.Reason,
+	}
+
+	var res struct {
+		StatusCode    string `json:"status_code"`
+		StatusMessage string `json:"status_message"`
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/refund", m.coreURL, url.PathEscape(req.OrderID))
+	if _, err := m.do(ctx, http.MethodPost, endpoint, body, &res); err != nil {
+		return err
+	}
+
+	if res.StatusCode != "200" {
+		return fmt.Errorf("midtrans refund failed with status %s: %s", res.StatusCode, res.StatusMessage)
+	}
+
+	return nil
+}
+
+// ChargedAmount is the total rounded to whole rupiah, as sent in CreateCharge
+func (m *MidtransProvider) ChargedAmount(amount domain.Money) domain.Money {
+	return domain.NewMoneyFromFloat(float64(midtransAmount(amount)))
+}
+
+// midtransAmount converts to the whole rupiah amounts the API expects
+func midtransAmount(amount domain.Money) int64 {
+	return int64(math.Round(amount.Float64()))
+}
+
+func (m *MidtransProvider) signature(orderID, statusCode, grossAmount string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + m.serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *MidtransProvider) toNotification(payload midtransNotification) *Notification {
+	notification := &Notification{
+		OrderID:       payload.OrderID,
+		TransactionID: payload.TransactionID,
+		RawStatus:     payload.TransactionStatus,
+		GrossAmount:   payload.GrossAmount,
+		Status:        StatusFailed,
+	}
+
+	switch payload.TransactionStatus {
+	case midtransTransactionCapture:
+		if payload.FraudStatus == midtransFraudStatusAccept {
+			notification.Status = StatusPaid
+		} else {
+			notification.Status = StatusPending
+		}
+	case midtransTransactionSettled:
+		notification.Status = StatusPaid
+	case midtransTransactionPending:
+		notification.Status = StatusPending
+	case midtransTransactionRefund:
+		notification.Status = StatusRefunded
+	}
+
+	return notification
+}
+
+func (m *MidtransProvider) do(ctx context.Context, method, endpoint string, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal midtrans request: %w", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build midtrans request: %w", err)
+	}
+	req.SetBasicAuth(m.serverKey, "")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := m.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("midtrans request failed: %w", err)
+	}
+	defer res.Body.Close()
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return res.StatusCode, fmt.Errorf("failed to decode midtrans response: %w", err)
+	}
+
+	return res.StatusCode, nil
+}
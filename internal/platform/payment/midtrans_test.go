@@ -0,0 +1,90 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-war-ticket-service/internal/domain"
+	"net/http"
+	"testing"
+)
+
+func TestMidtransAmountRoundsToWholeRupiah(t *testing.T) {
+	tests := []struct {
+		amount domain.Money
+		want   int64
+	}{
+		{amount: 15000000, want: 150000},
+		{amount: 15000049, want: 150000},
+		{amount: 15000050, want: 150001},
+		{amount: 99, want: 1},
+		{amount: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := midtransAmount(tt.amount); got != tt.want {
+			t.Errorf("midtransAmount(%s) = %d, want %d", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestMidtransChargedAmountMatchesCharge(t *testing.T) {
+	m := NewMidtransProvider("server-key", false)
+
+	// The charge is created for the rounded total, so that is what a
+	// settlement reports back
+	if got := m.ChargedAmount(15000050); got != 15000100 {
+		t.Fatalf("ChargedAmount = %s, want 150001.00", got)
+	}
+	if got := m.ChargedAmount(15000000); got != 15000000 {
+		t.Fatalf("ChargedAmount = %s, want 150000.00", got)
+	}
+}
+
+func midtransWebhook(t *testing.T, serverKey, orderID, statusCode, grossAmount, status string) []byte {
+	t.Helper()
+
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	body, err := json.Marshal(map[string]string{
+		"order_id":           orderID,
+		"status_code":        statusCode,
+		"gross_amount":       grossAmount,
+		"transaction_status": status,
+		"signature_key":      hex.EncodeToString(sum[:]),
+	})
+	if err != nil {
+		t.Fatalf("marshal webhook: %v", err)
+	}
+	return body
+}
+
+func TestMidtransParseWebhookVerifiesSignature(t *testing.T) {
+	m := NewMidtransProvider("server-key", false)
+
+	body := midtransWebhook(t, "server-key", "WT-TEST", "200", "150000.00", "settlement")
+	notification, err := m.ParseWebhook(context.Background(), body, http.Header{})
+	if err != nil {
+		t.Fatalf("valid webhook rejected: %v", err)
+	}
+	if notification.OrderID != "WT-TEST" || notification.Status != StatusPaid || notification.GrossAmount != "150000.00" {
+		t.Fatalf("unexpected notification %+v", notification)
+	}
+
+	forged := midtransWebhook(t, "other-key", "WT-TEST", "200", "150000.00", "settlement")
+	if _, err := m.ParseWebhook(context.Background(), forged, http.Header{}); !errors.Is(err, domain.ErrInvalidPaymentSignature) {
+		t.Fatalf("webhook signed with another key: expected ErrInvalidPaymentSignature, got %v", err)
+	}
+
+	// Changing the amount after signing breaks the signature
+	tampered := bytes.Replace(body, []byte(`"150000.00"`), []byte(`"1.00"`), 1)
+	if _, err := m.ParseWebhook(context.Background(), tampered, http.Header{}); !errors.Is(err, domain.ErrInvalidPaymentSignature) {
+		t.Fatalf("tampered webhook: expected ErrInvalidPaymentSignature, got %v", err)
+	}
+
+	if _, err := m.ParseWebhook(context.Background(), []byte("not json"), http.Header{}); !errors.Is(err, domain.ErrInvalidPaymentSignature) {
+		t.Fatalf("malformed webhook: expected ErrInvalidPaymentSignature, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package payment
+
+import (
+	"go-war-ticket-service/internal/domain"
+	"time"
+)
+
+// Status is the provider independent state of a payment
+type Status string
+
+const (
+	StatusPending  Status = "PENDING"
+	StatusPaid     Status = "PAID"
+	StatusFailed   Status = "FAILED"
+	StatusRefunded Status = "REFUNDED"
+)
+
+type ChargeRequest struct {
+	OrderID   string       // booking ID, used as the provider's order reference
+	Amount    domain.Money // total to charge, UnitPrice x Quantity + Fee - Discount
+	Currency  string
+	UnitPrice domain.Money
+	Quantity  int
+	Fee       domain.Money
+	Discount  domain.Money
+	ItemName  string
+	ExpiresIn time.Duration
+}
+
+type RefundRequest struct {
+	OrderID string
+	Amount  domain.Money
+	Reason  string
+}
+
+type Charge struct {
+	Reference   string
+	RedirectURL string
+}
+
+// Notification is a verified payment status update from a provider,
+// either pushed through a webhook or pulled with a status query
+type Notification struct {
+	OrderID       string
+	TransactionID string
+	Status        Status
+	RawStatus     string
+	GrossAmount   string // as reported by the provider, empty if not reported
+}
@@ -21,6 +21,7 @@ type TicketData struct {
 	ImageExtension   consts.Extension
 	OrderID          string
 	TicketCode       string
+	QRPayload        string // signed token encoded in the QR, falls back to TicketCode
 }
 
 type marotoGenerator struct{}
@@ -126,7 +127,12 @@ func (m *marotoGenerator) GenerateTicket(data TicketData) ([]byte, error) {
 
 		// Right: QR Code
 		p.Col(4, func() {
-			p.QrCode(data.TicketCode, props.Rect{
+			qrPayload := data.QRPayload
+			if qrPayload == "" {
+				qrPayload = data.TicketCode
+			}
+
+			p.QrCode(qrPayload, props.Rect{
 				Center:  true,
 				Percent: 100,
 			})
@@ -1,6 +1,7 @@
 package responses
 
 import (
+	"errors"
 	"go-war-ticket-service/internal/domain"
 
 	"github.com/gofiber/fiber/v2"
@@ -56,6 +57,14 @@ func ValidationError(c *fiber.Ctx, errors any) error {
 
 // UsecaseError maps domain errors to appropriate HTTP responses
 func UsecaseError(c *fiber.Ctx, err error) error {
+	// Errors wrapped with extra context keep their detailed message
+	switch {
+	case errors.Is(err, domain.ErrTicketAlreadyUsed):
+		return Error(c, fiber.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidOrderTransition):
+		return Error(c, fiber.StatusConflict, err.Error())
+	}
+
 	switch err {
 	case domain.ErrEmailAlreadyExists:
 		return Error(c, fiber.StatusBadRequest, err.Error())
@@ -65,18 +74,78 @@ func UsecaseError(c *fiber.Ctx, err error) error {
 		return Error(c, fiber.StatusNotFound, err.Error())
 	case domain.ErrInvalidCredentials:
 		return Error(c, fiber.StatusUnauthorized, err.Error())
+	case domain.ErrInvalidRefreshToken:
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	case domain.ErrRefreshTokenReused:
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	case domain.ErrInvalidUserToken:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrEmailNotVerified:
+		return Error(c, fiber.StatusForbidden, err.Error())
+	case domain.ErrEmailAlreadyVerified:
+		return Error(c, fiber.StatusConflict, err.Error())
 	case domain.ErrInvalidID:
 		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrInvalidRole:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrForbidden:
+		return Error(c, fiber.StatusForbidden, err.Error())
 	case domain.ErrInvalidStock:
 		return Error(c, fiber.StatusBadRequest, err.Error())
 	case domain.ErrInvalidPrice:
 		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrInvalidMoney:
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	case domain.ErrInvalidDate:
 		return Error(c, fiber.StatusBadRequest, err.Error())
 	case domain.ErrEventNotFound:
 		return Error(c, fiber.StatusNotFound, err.Error())
 	case domain.ErrNotEnoughStock:
 		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrStockBelowSold:
+		return Error(c, fiber.StatusConflict, err.Error())
+	case domain.ErrTierNotFound:
+		return Error(c, fiber.StatusNotFound, err.Error())
+	case domain.ErrTierNotOnSale:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrTierLimitExceeded:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrTierRequired:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrPurchaseLimitExceeded:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrNotInWaitingRoom:
+		return Error(c, fiber.StatusNotFound, err.Error())
+	case domain.ErrAdmissionRequired:
+		return Error(c, fiber.StatusForbidden, err.Error())
+	case domain.ErrWaitingRoomClosed:
+		return Error(c, fiber.StatusNotFound, err.Error())
+	case domain.ErrQueueNotFound:
+		return Error(c, fiber.StatusNotFound, err.Error())
+	case domain.ErrOrderNotFound:
+		return Error(c, fiber.StatusNotFound, err.Error())
+	case domain.ErrOrderExpired:
+		return Error(c, fiber.StatusConflict, err.Error())
+	case domain.ErrOrderCancelled:
+		return Error(c, fiber.StatusConflict, err.Error())
+	case domain.ErrOrderNotFailed:
+		return Error(c, fiber.StatusConflict, err.Error())
+	case domain.ErrInvalidPaymentSignature:
+		return Error(c, fiber.StatusUnauthorized, err.Error())
+	case domain.ErrPaymentAmountMismatch:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrPaymentUnavailable:
+		return Error(c, fiber.StatusServiceUnavailable, err.Error())
+	case domain.ErrTicketNotFound:
+		return Error(c, fiber.StatusNotFound, err.Error())
+	case domain.ErrTicketRevoked:
+		return Error(c, fiber.StatusConflict, err.Error())
+	case domain.ErrTicketNotActive:
+		return Error(c, fiber.StatusConflict, err.Error())
+	case domain.ErrInvalidTicketToken:
+		return Error(c, fiber.StatusBadRequest, err.Error())
+	case domain.ErrTicketTokenExpired:
+		return Error(c, fiber.StatusBadRequest, err.Error())
 	default:
 		return Error(c, fiber.StatusInternalServerError, domain.ErrInternal.Error())
 	}
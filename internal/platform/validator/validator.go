@@ -55,6 +55,8 @@ func (v *Validator) formatErrorMessage(fe validator.FieldError) string {
 		return fmt.Sprintf("must be at most %s characters long", fe.Param())
 	case "base64":
 		return "must be a valid base64 encoded string"
+	case "required_without":
+		return fmt.Sprintf("this field is required when %s is empty", strings.ToLower(fe.Param()))
 	default:
 		// Fallback message
 		return fmt.Sprintf("invalid value for tag '%s'", fe.Tag())
@@ -8,11 +8,63 @@ type userID uuid.UUID
 // UserID is the key used to store and retrieve user ID from context
 var UserID userID
 
+// For strong typing of user role in context
+type userRole string
+
+// UserRole is the key used to store and retrieve the user's role from context
+var UserRole userRole
+
+// For strong typing of the access token claims in context
+type tokenClaim string
+
+// For strong typing of the waiting room admission in context
+type admittedEventID uuid.UUID
+
+// AdmittedEventID is the key for the event a request was admitted to
+var AdmittedEventID admittedEventID
+
 var (
-	EventStockKey = "event_stock:%s"
+	// TokenID is the key for the access token's jti claim in context
+	TokenID tokenClaim = "jti"
+	// TokenExpiresAt is the key for the access token's expiry in context
+	TokenExpiresAt tokenClaim = "exp"
+)
+
+var (
+	// Stock is tracked per ticket tier: event_stock:<event_id>:<tier_id>
+	EventTierStockKey = "event_stock:%s:%s"
+
+	// Seats an order took from a tier's stock: stock_reservation:<booking_id>
+	StockReservationKey = "stock_reservation:%s"
+
+	// Tickets a user holds for an event: user_purchases:<event_id>:<user_id>
+	UserPurchaseCountKey = "user_purchases:%s:%s"
+
+	// Waiting room, FIFO of user IDs scored by join order: waiting_room:queue:<event_id>
+	WaitingRoomQueueKey = "waiting_room:queue:%s"
+	// Join counter used as the queue score: waiting_room:seq:<event_id>
+	WaitingRoomSeqKey = "waiting_room:seq:%s"
+	// A user's admission token while queued or admitted: waiting_room:entry:<event_id>:<user_id>
+	WaitingRoomEntryKey = "waiting_room:entry:%s:%s"
+	// Reverse lookup of an admission token: waiting_room:token:<token>
+	WaitingRoomTokenKey = "waiting_room:token:%s"
+	// Set while a user may place orders: waiting_room:admitted:<event_id>:<user_id>
+	WaitingRoomAdmittedKey = "waiting_room:admitted:%s:%s"
+	// Events that currently have people queued
+	WaitingRoomEventsKey = "waiting_room:events"
+	// Held by the instance running the admitter for the current tick
+	WaitingRoomAdmitterLockKey = "waiting_room:admitter:lock"
+
+	// Revoked access tokens by jti, kept until the token would have expired
+	AccessTokenDenylistKey = "auth:denylist:%s"
+
+	// Stored responses for Idempotency-Key retries: idempotency:<user_id>:<key hash>
+	IdempotencyKey = "idempotency:%s:%s"
 )
 
 const (
 	// Queue Names
 	QueueTicketGeneration = "ticket_generation_queue"
+	QueueOrderRefunded    = "order_refunded_queue"
+	QueueTicketsReady     = "tickets_ready_queue"
 )
@@ -3,7 +3,9 @@ package contextutil
 import (
 	"context"
 	"errors"
+	"go-war-ticket-service/internal/domain"
 	"go-war-ticket-service/internal/utils"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -16,3 +18,38 @@ func GetUserID(ctx context.Context) (uuid.UUID, error) {
 
 	return id, nil
 }
+
+func GetUserRole(ctx context.Context) (domain.UserRole, error) {
+	role, ok := ctx.Value(utils.UserRole).(domain.UserRole)
+	if !ok {
+		return "", errors.New("user role not found in context")
+	}
+
+	return role, nil
+}
+
+// GetTokenID returns the jti of the access token used for the request
+func GetTokenID(ctx context.Context) (string, error) {
+	jti, ok := ctx.Value(utils.TokenID).(string)
+	if !ok || jti == "" {
+		return "", errors.New("token ID not found in context")
+	}
+
+	return jti, nil
+}
+
+func GetTokenExpiresAt(ctx context.Context) (time.Time, error) {
+	exp, ok := ctx.Value(utils.TokenExpiresAt).(time.Time)
+	if !ok {
+		return time.Time{}, errors.New("token expiry not found in context")
+	}
+
+	return exp, nil
+}
+
+// GetAdmittedEventID returns the event the waiting room admitted the request
+// to. ok is false when the waiting room is disabled.
+func GetAdmittedEventID(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(utils.AdmittedEventID).(uuid.UUID)
+	return id, ok
+}
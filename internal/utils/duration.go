@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration works like time.ParseDuration but also accepts whole days,
+// e.g. "30d", which is how token lifetimes are written in the config
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(s)
+}
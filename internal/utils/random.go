@@ -1,26 +1,32 @@
 package utils
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"math/big"
 )
 
 func GenerateRandomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	seededRand := rand.New(rand.NewSource(time.Now().UnixNano()))
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[seededRand.Intn(len(charset))]
-	}
-	return string(b)
+	return randomFromCharset(charset, length)
 }
 
 func GenerateRandomNumberString(length int) string {
 	const charset = "0123456789"
-	seededRand := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return randomFromCharset(charset, length)
+}
+
+// randomFromCharset draws from crypto/rand, so concurrent calls never repeat
+// each other the way a clock-seeded math/rand source does
+func randomFromCharset(charset string, length int) string {
+	max := big.NewInt(int64(len(charset)))
 	b := make([]byte, length)
 	for i := range b {
-		b[i] = charset[seededRand.Intn(len(charset))]
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			// crypto/rand only fails when the OS entropy source is broken
+			panic(err)
+		}
+		b[i] = charset[n.Int64()]
 	}
 	return string(b)
 }